@@ -10,23 +10,86 @@ import (
 	"fmt"
 	"github.com/KokoiRuby/k8s-copilot/cmd/funcs"
 	"github.com/KokoiRuby/k8s-copilot/cmd/utils"
-	"github.com/sashabaranov/go-openai"
 	"github.com/sashabaranov/go-openai/jsonschema"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
 
-var tools []openai.Tool
+var tools []utils.Tool
+
+// router picks the functions to call, generator generates manifests for them.
+var router, generator utils.LLM
+
+// clientGo talks to the cluster.
+var clientGo *utils.ClientGo
+
+// maxSteps limits the rounds of function calling per query.
+var maxSteps int
+
+// invoke invokes the function asked by the model, it's swapped by tests.
+var invoke = invokeFunc
+
+// stream prints the reply as it's produced.
+var stream bool
+
+// maxHistoryTokens limits the size of dialogue remembered across queries.
+var maxHistoryTokens int
+
+var sysPrompt = `
+You're a Copilot for Kubernetes.
+Use the given tools to fulfill the user's request, you may call them multiple times,
+e.g. list resources first to find the exact name, then act on it.
+Once the request is fulfilled, reply with a short summary of what has been done.
+`
 
 // chatgptCmd represents the chatgpt command
 var chatgptCmd = &cobra.Command{
 	Use:   "chatgpt",
 	Short: "ChatGPT",
 	Long: `Start an interactive window where you can input the queries.
+Previous queries in the session are remembered, type "/reset" to forget them.
+Type "/model [gen] [name]" to show or switch the model to pick functions (or generate manifests).
+Type "/forwards" to list port-forwards, "/stop <id|all>" to stop them, they're stopped on exit as well.
 Type [exit|quit|q|bye] and press "Enter" to exit.`,
-	Run: func(cmd *cobra.Command, args []string) {
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if maxSteps < 1 {
+			return fmt.Errorf("--max-steps must be at least 1, got %d", maxSteps)
+		}
+		if maxHistoryTokens < 1 {
+			return fmt.Errorf("--max-history-tokens must be at least 1, got %d", maxHistoryTokens)
+		}
+		if funcs.Output != "" && !slices.Contains(funcs.Outputs, funcs.Output) {
+			return fmt.Errorf("--output must be one of %s, got %s", strings.Join(funcs.Outputs, "|"), funcs.Output)
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// errors from here on are not caused by misuse
+		cmd.SilenceUsage = true
+
+		var err error
+		router, err = utils.NewLLM(llmConfig)
+		if err != nil {
+			return err
+		}
+		generator, err = utils.NewLLM(llmConfig)
+		if err != nil {
+			return err
+		}
+		if genModel == "" {
+			genModel = router.Model()
+		}
+		generator.SetModel(genModel)
+
+		clientGo, err = utils.NewClientGo(kubeconfig, kubeContext, namespace)
+		if err != nil {
+			return err
+		}
 		startToChat()
+		return nil
 	},
 }
 
@@ -42,81 +105,180 @@ func init() {
 	// Cobra supports local flags which will only run when this command
 	// is called directly, e.g.:
 	// chatgptCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	chatgptCmd.Flags().IntVarP(&maxSteps, "max-steps", "s", 10, "maximum rounds of function calling per query.")
+	chatgptCmd.Flags().BoolVar(&stream, "stream", true, "print the reply as it's produced.")
+	chatgptCmd.Flags().StringVarP(&funcs.Output, "output", "o", "", "default output format of resources, one of table|wide|yaml|json|name, lists are shown as table & single objects as yaml if not given.")
+	chatgptCmd.Flags().IntVar(&maxHistoryTokens, "max-history-tokens", 8000, "rough token budget of the dialogue remembered across queries.")
 }
 
 // 1. startToChat retrieves user input from stdin & prepares to process it.
 func startToChat() {
 	tools = buildTools()
 
+	session := newHistory(maxHistoryTokens)
 	scanner := bufio.NewScanner(os.Stdin)
 	fmt.Println("Greetings, I'm a Copilot for Kubernetes, you require my assistant?")
+	// port-forwards don't outlive the session
+	defer funcs.StopForwards()
 
 	for {
 		ctx := context.Background()
 		fmt.Print("> ")
-		if scanner.Scan() {
-			input := scanner.Text()
-			if input == "exit" || input == "quit" || input == "q" || input == "bye" {
-				fmt.Println("Have a good day, Bye!;)")
-				break
-			}
-			if input == "" {
-				continue
-			}
-			//fmt.Println("Your query is:", input)
-			fmt.Println(processInput(ctx, input))
+		if !scanner.Scan() {
+			// stdin is closed
+			fmt.Println()
+			break
+		}
+		input := scanner.Text()
+		if input == "exit" || input == "quit" || input == "q" || input == "bye" {
+			fmt.Println("Have a good day, Bye!;)")
+			break
+		}
+		if input == "" {
+			continue
+		}
+		if strings.HasPrefix(input, "/") {
+			handleCommand(input, session)
+			continue
+		}
+		//fmt.Println("Your query is:", input)
+		if resp := processInput(ctx, input, session); resp != "" {
+			fmt.Println(resp)
 		}
 	}
 }
 
-// 2. processInput processes user input by function calling.
-func processInput(ctx context.Context, input string) string {
-	client, err := utils.NewOpenAI()
-	if err != nil {
-		return err.Error()
+// handleCommand handles the REPL commands starting with "/".
+func handleCommand(input string, session *history) {
+	fields := strings.Fields(input)
+	switch fields[0] {
+	case "/reset":
+		session.reset()
+		fmt.Println("Conversation history cleared.")
+	case "/model":
+		switch {
+		case len(fields) == 1:
+			fmt.Printf("Model to pick functions: [%s], model to generate manifests: [%s]\n", router.Model(), generator.Model())
+		case len(fields) == 2 && fields[1] != "gen":
+			router.SetModel(fields[1])
+			fmt.Printf("Switched to model [%s] to pick functions.\n", fields[1])
+		case len(fields) == 3 && fields[1] == "gen":
+			generator.SetModel(fields[2])
+			fmt.Printf("Switched to model [%s] to generate manifests.\n", fields[2])
+		default:
+			fmt.Println("Usage: /model [gen] [name]")
+		}
+	case "/forwards":
+		fmt.Println(funcs.ListForwards())
+	case "/stop":
+		if len(fields) != 2 {
+			fmt.Println("Usage: /stop <id|all>")
+			return
+		}
+		msg, err := funcs.StopForward(fields[1])
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		fmt.Println(msg)
+	default:
+		fmt.Printf("Unknown command [%s], supported: /reset, /model, /forwards, /stop\n", fields[0])
 	}
-	resp := funcCalling(ctx, input, client)
+}
+
+// 2. processInput processes user input by function calling.
+func processInput(ctx context.Context, input string, session *history) string {
+	resp := funcCalling(ctx, input, session, router, generator)
 	return resp
 }
 
-// 3. funcCalling defines the functions & prepares to invoke.
-func funcCalling(ctx context.Context, input string, client *utils.OpenAI) string {
-	dialogue := []openai.ChatCompletionMessage{
-		{
-			Role:    openai.ChatMessageRoleUser,
-			Content: input,
-		},
-	}
+// 3. funcCalling runs the agent loop, it invokes every function the model asks for
+// & feeds the results back until the model gives a final answer or the step limit is hit.
+func funcCalling(ctx context.Context, input string, session *history, router, generator utils.LLM) string {
+	session.add(utils.Message{
+		Role:    utils.RoleUser,
+		Content: input,
+	})
 
-	resp, err := client.Client.CreateChatCompletion(ctx,
-		openai.ChatCompletionRequest{
-			Model:    openai.GPT4oMini,
-			Messages: dialogue,
-			Tools:    tools,
-		},
-	)
-	if err != nil {
-		return err.Error()
+	for step := 0; step < maxSteps; step++ {
+		session.trim()
+		msg, streamed, err := chat(ctx, router, session.dialogue())
+		if err != nil {
+			return err.Error()
+		}
+
+		// the model may give nothing, e.g. filtered by the provider, don't remember an empty answer
+		if len(msg.ToolCalls) == 0 && msg.Content == "" {
+			return "No answer from the model, try to refine your query."
+		}
+
+		// build chat history
+		session.add(msg)
+
+		// no more function to call, it's the final answer
+		if len(msg.ToolCalls) == 0 {
+			if streamed {
+				return ""
+			}
+			return msg.Content
+		}
+
+		for _, toolCall := range msg.ToolCalls {
+			result, err := invoke(ctx, generator, toolCall.Name, toolCall.Arguments)
+			if err != nil {
+				// feed the error back as well so that the model could correct itself
+				result = fmt.Sprintf("Error: %s", err.Error())
+			}
+			if result == "" {
+				result = "(empty)"
+			}
+			session.add(utils.Message{
+				Role:       utils.RoleTool,
+				Content:    result,
+				ToolCallID: toolCall.ID,
+			})
+		}
 	}
+	return fmt.Sprintf("No final answer after [%d] steps, try to refine your query or raise --max-steps", maxSteps)
+}
 
-	msg := resp.Choices[0].Message
-	if len(msg.ToolCalls) != 1 {
-		return fmt.Sprintf("No appropriate tool is found, %v", len(msg.ToolCalls))
+// chat sends the dialogue to the model, the reply is printed as it's produced if streaming is supported,
+// it reports whether the content of reply has been printed.
+func chat(ctx context.Context, client utils.LLM, dialogue []utils.Message) (utils.Message, bool, error) {
+	streamClient, ok := client.(utils.StreamLLM)
+	if !stream || !ok {
+		msg, err := client.Chat(ctx, dialogue, tools)
+		return msg, false, err
 	}
 
-	// build chat history
-	dialogue = append(dialogue, msg)
-	//return fmt.Sprintf("Function to call: %s, arg: %s", msg.ToolCalls[0].Function.Name, msg.ToolCalls[0].Function.Arguments)
-	//fmt.Printf("Function to call: %s, arg: %s\n", msg.ToolCalls[0].Function.Name, msg.ToolCalls[0].Function.Arguments)
-	result, err := invokeFunc(ctx, client, msg.ToolCalls[0].Function.Name, msg.ToolCalls[0].Function.Arguments)
-	if err != nil {
-		return err.Error()
+	printed := false
+	msg, err := streamClient.ChatStream(ctx, dialogue, tools, func(content string) {
+		printed = true
+		fmt.Print(content)
+	})
+	if printed {
+		fmt.Println()
 	}
-	return result
+	return msg, printed, err
+}
+
+// mutatingFuncs are the functions changing the cluster, disabled in read-only mode.
+var mutatingFuncs = map[string]bool{
+	"createResource": true,
+	"updateResource": true,
+	"deleteResource": true,
+	"applyResource":  true,
+	"scaleResource":  true,
+	"rolloutRestart": true,
+	"rolloutUndo":    true,
+	"execCommand":    true,
 }
 
 // 4. invokeFunc invokes the function
-func invokeFunc(ctx context.Context, client *utils.OpenAI, name, args string) (string, error) {
+func invokeFunc(ctx context.Context, client utils.LLM, name, args string) (string, error) {
+	if readOnly && mutatingFuncs[name] {
+		return "", fmt.Errorf("function %s is disabled in read-only mode", name)
+	}
 	switch name {
 	case "createResource":
 		params := struct {
@@ -125,16 +287,20 @@ func invokeFunc(ctx context.Context, client *utils.OpenAI, name, args string) (s
 		if err := json.Unmarshal([]byte(args), &params); err != nil {
 			return "", err
 		}
-		return funcs.CreateResource(ctx, client, params.Input, kubeconfig)
+		return funcs.CreateResource(ctx, client, params.Input, clientGo)
 	case "listResource":
 		params := struct {
-			Namespace string `json:"namespace"`
-			Resource  string `json:"resource"`
+			Namespace     string `json:"namespace"`
+			Resource      string `json:"resource"`
+			LabelSelector string `json:"label_selector"`
+			FieldSelector string `json:"field_selector"`
+			Output        string `json:"output"`
+			AllNamespaces bool   `json:"all_namespaces"`
 		}{}
 		if err := json.Unmarshal([]byte(args), &params); err != nil {
 			return "", err
 		}
-		return funcs.ListResource(ctx, params.Namespace, params.Resource, kubeconfig)
+		return funcs.ListResource(ctx, params.Namespace, params.Resource, params.LabelSelector, params.FieldSelector, params.Output, params.AllNamespaces, clientGo)
 	case "updateResource":
 		params := struct {
 			Namespace    string `json:"namespace"`
@@ -145,7 +311,7 @@ func invokeFunc(ctx context.Context, client *utils.OpenAI, name, args string) (s
 		if err := json.Unmarshal([]byte(args), &params); err != nil {
 			return "", err
 		}
-		return funcs.UpdateResource(ctx, client, params.Namespace, params.Resource, params.ResourceName, params.Delta, kubeconfig)
+		return funcs.UpdateResource(ctx, client, params.Namespace, params.Resource, params.ResourceName, params.Delta, clientGo)
 	case "deleteResource":
 		params := struct {
 			Namespace    string `json:"namespace"`
@@ -155,14 +321,119 @@ func invokeFunc(ctx context.Context, client *utils.OpenAI, name, args string) (s
 		if err := json.Unmarshal([]byte(args), &params); err != nil {
 			return "", err
 		}
-		return funcs.DeleteResource(ctx, params.Namespace, params.Resource, params.ResourceName, kubeconfig)
+		return funcs.DeleteResource(ctx, params.Namespace, params.Resource, params.ResourceName, clientGo)
+	case "applyResource":
+		params := struct {
+			Input string `json:"input"`
+			Force bool   `json:"force"`
+		}{}
+		if err := json.Unmarshal([]byte(args), &params); err != nil {
+			return "", err
+		}
+		return funcs.ApplyResource(ctx, client, params.Input, params.Force, clientGo)
+	case "getResource":
+		params := struct {
+			Namespace    string `json:"namespace"`
+			Resource     string `json:"resource"`
+			ResourceName string `json:"resource_name"`
+			Output       string `json:"output"`
+		}{}
+		if err := json.Unmarshal([]byte(args), &params); err != nil {
+			return "", err
+		}
+		return funcs.GetResource(ctx, params.Namespace, params.Resource, params.ResourceName, params.Output, clientGo)
+	case "describeResource":
+		params := struct {
+			Namespace    string `json:"namespace"`
+			Resource     string `json:"resource"`
+			ResourceName string `json:"resource_name"`
+		}{}
+		if err := json.Unmarshal([]byte(args), &params); err != nil {
+			return "", err
+		}
+		return funcs.DescribeResource(ctx, params.Namespace, params.Resource, params.ResourceName, clientGo)
+	case "scaleResource":
+		params := struct {
+			Namespace    string `json:"namespace"`
+			Resource     string `json:"resource"`
+			ResourceName string `json:"resource_name"`
+			Replicas     *int64 `json:"replicas"`
+			Wait         bool   `json:"wait"`
+		}{}
+		if err := json.Unmarshal([]byte(args), &params); err != nil {
+			return "", err
+		}
+		// a missing replicas must not scale the resource down to 0
+		if params.Replicas == nil {
+			return "", fmt.Errorf("replicas must be given to scale resource [%s]", params.ResourceName)
+		}
+		return funcs.ScaleResource(ctx, params.Namespace, params.Resource, params.ResourceName, *params.Replicas, params.Wait, clientGo)
+	case "rolloutRestart", "rolloutStatus", "rolloutHistory", "rolloutUndo":
+		params := struct {
+			Namespace    string `json:"namespace"`
+			Resource     string `json:"resource"`
+			ResourceName string `json:"resource_name"`
+			Timeout      string `json:"timeout"`
+			ToRevision   int64  `json:"to_revision"`
+		}{}
+		if err := json.Unmarshal([]byte(args), &params); err != nil {
+			return "", err
+		}
+		switch name {
+		case "rolloutRestart":
+			return funcs.RolloutRestart(ctx, params.Namespace, params.Resource, params.ResourceName, clientGo)
+		case "rolloutStatus":
+			return funcs.RolloutStatus(ctx, params.Namespace, params.Resource, params.ResourceName, params.Timeout, clientGo)
+		case "rolloutHistory":
+			return funcs.RolloutHistory(ctx, params.Namespace, params.Resource, params.ResourceName, clientGo)
+		default:
+			return funcs.RolloutUndo(ctx, params.Namespace, params.Resource, params.ResourceName, params.ToRevision, clientGo)
+		}
+	case "execCommand":
+		params := struct {
+			Namespace    string   `json:"namespace"`
+			Resource     string   `json:"resource"`
+			ResourceName string   `json:"resource_name"`
+			Container    string   `json:"container"`
+			Command      []string `json:"command"`
+		}{}
+		if err := json.Unmarshal([]byte(args), &params); err != nil {
+			return "", err
+		}
+		return funcs.ExecCommand(ctx, params.Namespace, params.Resource, params.ResourceName, params.Container, params.Command, clientGo)
+	case "portForward":
+		params := struct {
+			Namespace    string   `json:"namespace"`
+			Resource     string   `json:"resource"`
+			ResourceName string   `json:"resource_name"`
+			Ports        []string `json:"ports"`
+		}{}
+		if err := json.Unmarshal([]byte(args), &params); err != nil {
+			return "", err
+		}
+		return funcs.PortForward(ctx, params.Namespace, params.Resource, params.ResourceName, params.Ports, clientGo)
+	case "getLogs":
+		params := struct {
+			Namespace    string `json:"namespace"`
+			Resource     string `json:"resource"`
+			ResourceName string `json:"resource_name"`
+			Container    string `json:"container"`
+			Previous     bool   `json:"previous"`
+			TailLines    int64  `json:"tail_lines"`
+			Since        string `json:"since"`
+			LimitBytes   int64  `json:"limit_bytes"`
+		}{}
+		if err := json.Unmarshal([]byte(args), &params); err != nil {
+			return "", err
+		}
+		return funcs.GetLogs(ctx, params.Namespace, params.Resource, params.ResourceName, params.Container, params.Previous, params.TailLines, params.Since, params.LimitBytes, clientGo)
 	default:
 		return "", fmt.Errorf("unknown function %s", name)
 	}
 }
 
-func buildTools() []openai.Tool {
-	f1 := openai.FunctionDefinition{
+func buildTools() []utils.Tool {
+	t1 := utils.Tool{
 		Name:        "createResource",
 		Description: "Create Kubernetes resource YAML manifest",
 		Parameters: jsonschema.Definition{
@@ -176,39 +447,53 @@ func buildTools() []openai.Tool {
 			Required: []string{"input"},
 		},
 	}
-	t1 := openai.Tool{
-		Type:     openai.ToolTypeFunction,
-		Function: &f1,
-	}
 
-	f2 := openai.FunctionDefinition{
+	t2 := utils.Tool{
 		Name:        "listResource",
-		Description: "List Kubernetes resources",
+		Description: "List Kubernetes resources as a table like kubectl get, with columns such as READY, STATUS, RESTARTS & AGE",
 		Parameters: jsonschema.Definition{
 			Type: jsonschema.Object,
 			Properties: map[string]jsonschema.Definition{
 				"namespace": {
 					Type: jsonschema.String,
-					Description: `The namespace where resource is. 
+					Description: `The namespace where resource is. If not given, the current namespace is used.
 For non-namespaced resources, such as namespaces, persistentvolumes, 
 this field shall not be set.`,
+				},
+				"all_namespaces": {
+					Type: jsonschema.Boolean,
+					Description: `List resources across all namespaces as kubectl get -A, namespace is ignored then.
+Set it to true when the user asks about the whole cluster, e.g. every failing pod in the cluster.`,
 				},
 				"resource": {
 					Type: jsonschema.String,
-					Description: `K8s built-in resource from 'kubectl api-resources'. 
-For example: pods, deployments, services.
-Use full name rather than short name`,
+					Description: `Resource type from 'kubectl api-resources', including custom resources.
+Plural, singular, kind, short name or resource.group are accepted.
+For example: pods, deployment, svc, certificates.cert-manager.io`,
+				},
+				"label_selector": {
+					Type: jsonschema.String,
+					Description: `Label selector to filter resources, as kubectl get -l.
+For example: app=web, env in (prod,staging), !canary`,
+				},
+				"field_selector": {
+					Type: jsonschema.String,
+					Description: `Field selector to filter resources, as kubectl get --field-selector.
+Only a few fields are supported, e.g. metadata.name & metadata.namespace for all resources,
+status.phase & spec.nodeName for pods. For example: status.phase=Failed, spec.nodeName=worker-2`,
+				},
+				"output": {
+					Type: jsonschema.String,
+					Enum: funcs.Outputs,
+					Description: `Output format, table by default. wide adds columns like IP & NODE,
+json & yaml give full objects, json is preferred to inspect fields, name gives names only.`,
 				},
 			},
-			Required: []string{"namespace", "resource"},
+			Required: []string{"resource"},
 		},
 	}
-	t2 := openai.Tool{
-		Type:     openai.ToolTypeFunction,
-		Function: &f2,
-	}
 
-	f3 := openai.FunctionDefinition{
+	t3 := utils.Tool{
 		Name:        "updateResource",
 		Description: "Update Kubernetes resources",
 		Parameters: jsonschema.Definition{
@@ -216,15 +501,15 @@ Use full name rather than short name`,
 			Properties: map[string]jsonschema.Definition{
 				"namespace": {
 					Type: jsonschema.String,
-					Description: `The namespace where resource is. if not given, set it to "default".
+					Description: `The namespace where resource is. If not given, the current namespace is used.
 For non-namespaced resources, such as namespaces, persistentvolumes, 
 this field shall not be set.`,
 				},
 				"resource": {
 					Type: jsonschema.String,
-					Description: `K8s built-in resource from 'kubectl api-resources'. 
-For example: pods, deployments, services.
-Use full name rather than short name`,
+					Description: `Resource type from 'kubectl api-resources', including custom resources.
+Plural, singular, kind, short name or resource.group are accepted.
+For example: pods, deployment, svc, certificates.cert-manager.io`,
 				},
 				"resource_name": {
 					Type:        jsonschema.String,
@@ -235,15 +520,11 @@ Use full name rather than short name`,
 					Description: "The delta to update the resource.",
 				},
 			},
-			Required: []string{"namespace", "resource", "resource_name", "delta"},
+			Required: []string{"resource", "resource_name", "delta"},
 		},
 	}
-	t3 := openai.Tool{
-		Type:     openai.ToolTypeFunction,
-		Function: &f3,
-	}
 
-	f4 := openai.FunctionDefinition{
+	t4 := utils.Tool{
 		Name:        "deleteResource",
 		Description: "Delete Kubernetes resources",
 		Parameters: jsonschema.Definition{
@@ -251,28 +532,304 @@ Use full name rather than short name`,
 			Properties: map[string]jsonschema.Definition{
 				"namespace": {
 					Type: jsonschema.String,
-					Description: `The namespace where resource is. if not given, set it to "default".
+					Description: `The namespace where resource is. If not given, the current namespace is used.
 For non-namespaced resources, such as namespaces, persistentvolumes, 
 this field shall not be set.`,
 				},
 				"resource": {
 					Type: jsonschema.String,
-					Description: `K8s built-in resource from 'kubectl api-resources'. 
-For example: pods, deployments, services.
-Use full name rather than short name`,
+					Description: `Resource type from 'kubectl api-resources', including custom resources.
+Plural, singular, kind, short name or resource.group are accepted.
+For example: pods, deployment, svc, certificates.cert-manager.io`,
 				},
 				"resource_name": {
 					Type:        jsonschema.String,
 					Description: "Name of the resource to be deleted",
 				},
 			},
-			Required: []string{"namespace", "resource", "resource_name"},
+			Required: []string{"resource", "resource_name"},
 		},
 	}
-	t4 := openai.Tool{
-		Type:     openai.ToolTypeFunction,
-		Function: &f4,
+	t5 := utils.Tool{
+		Name: "applyResource",
+		Description: `Create or update Kubernetes resource idempotently by server-side apply.
+Use it when the user asks to "create or update" or "apply" a resource, which may already exist.`,
+		Parameters: jsonschema.Definition{
+			Type: jsonschema.Object,
+			Properties: map[string]jsonschema.Definition{
+				"input": {
+					Type:        jsonschema.String,
+					Description: "Extract verb, resource and necessary flags",
+				},
+				"force": {
+					Type: jsonschema.Boolean,
+					Description: `Take over the fields owned by other field managers on conflicts.
+Only set it to true when the user explicitly asks to force after a conflict is reported.`,
+				},
+			},
+			Required: []string{"input"},
+		},
 	}
 
-	return []openai.Tool{t1, t2, t3, t4}
+	t6 := utils.Tool{
+		Name:        "getResource",
+		Description: "Get a single Kubernetes resource, its manifest without managedFields by default, e.g. to find the image or replicas of a deployment",
+		Parameters: jsonschema.Definition{
+			Type: jsonschema.Object,
+			Properties: map[string]jsonschema.Definition{
+				"namespace": {
+					Type: jsonschema.String,
+					Description: `The namespace where resource is. If not given, the current namespace is used.
+For non-namespaced resources, such as namespaces, persistentvolumes, 
+this field shall not be set.`,
+				},
+				"resource": {
+					Type: jsonschema.String,
+					Description: `Resource type from 'kubectl api-resources', including custom resources.
+Plural, singular, kind, short name or resource.group are accepted.
+For example: pods, deployment, svc, certificates.cert-manager.io`,
+				},
+				"resource_name": {
+					Type:        jsonschema.String,
+					Description: "The name of the resource",
+				},
+				"output": {
+					Type: jsonschema.String,
+					Enum: funcs.Outputs,
+					Description: `Output format, yaml by default. table & wide give the row as kubectl get,
+json is preferred to inspect fields, name gives the name only.`,
+				},
+			},
+			Required: []string{"resource", "resource_name"},
+		},
+	}
+
+	t7 := utils.Tool{
+		Name: "describeResource",
+		Description: `Describe a single Kubernetes resource like kubectl describe, with spec highlights, status conditions,
+owner references & related events. Use it to find out why a resource is not working.`,
+		Parameters: jsonschema.Definition{
+			Type: jsonschema.Object,
+			Properties: map[string]jsonschema.Definition{
+				"namespace": {
+					Type: jsonschema.String,
+					Description: `The namespace where resource is. If not given, the current namespace is used.
+For non-namespaced resources, such as namespaces, persistentvolumes, 
+this field shall not be set.`,
+				},
+				"resource": {
+					Type: jsonschema.String,
+					Description: `Resource type from 'kubectl api-resources', including custom resources.
+Plural, singular, kind, short name or resource.group are accepted.
+For example: pods, deployment, svc, certificates.cert-manager.io`,
+				},
+				"resource_name": {
+					Type:        jsonschema.String,
+					Description: "The name of the resource",
+				},
+			},
+			Required: []string{"resource", "resource_name"},
+		},
+	}
+
+	t8 := utils.Tool{
+		Name:        "getLogs",
+		Description: "Get logs of a pod, or of the pods managed by a workload like deployment, statefulset, daemonset or job",
+		Parameters: jsonschema.Definition{
+			Type: jsonschema.Object,
+			Properties: map[string]jsonschema.Definition{
+				"namespace": {
+					Type:        jsonschema.String,
+					Description: "The namespace where the pod is. If not given, the current namespace is used.",
+				},
+				"resource": {
+					Type:        jsonschema.String,
+					Description: "Resource type of resource_name, pods by default. For example: pods, deployment, statefulset",
+				},
+				"resource_name": {
+					Type:        jsonschema.String,
+					Description: "The name of the pod or workload",
+				},
+				"container": {
+					Type:        jsonschema.String,
+					Description: "The container to read logs from. If not given, the default container of the pod is used.",
+				},
+				"previous": {
+					Type:        jsonschema.Boolean,
+					Description: "Read logs of the previous terminated container, e.g. to find out why it crashed.",
+				},
+				"tail_lines": {
+					Type:        jsonschema.Integer,
+					Description: "Number of the most recent lines to read, 100 by default unless since is given.",
+				},
+				"since": {
+					Type:        jsonschema.String,
+					Description: "Only read logs newer than the duration, e.g. 5m, 1h",
+				},
+				"limit_bytes": {
+					Type:        jsonschema.Integer,
+					Description: "Maximum bytes of logs to read, only the most recent ones are kept. 16384 by default, shared by the pods of a workload.",
+				},
+			},
+			Required: []string{"resource_name"},
+		},
+	}
+
+	t9 := utils.Tool{
+		Name: "scaleResource",
+		Description: `Scale Kubernetes resources serving the scale subresource to the given replicas,
+such as deployments, statefulsets, replicasets & some custom resources.
+Prefer it to updateResource when only replicas change.`,
+		Parameters: jsonschema.Definition{
+			Type: jsonschema.Object,
+			Properties: map[string]jsonschema.Definition{
+				"namespace": {
+					Type:        jsonschema.String,
+					Description: "The namespace where resource is. If not given, the current namespace is used.",
+				},
+				"resource": {
+					Type:        jsonschema.String,
+					Description: "Resource type to scale. For example: deployment, statefulset, replicaset",
+				},
+				"resource_name": {
+					Type:        jsonschema.String,
+					Description: "The name of the resource to scale",
+				},
+				"replicas": {
+					Type:        jsonschema.Integer,
+					Description: "The desired number of replicas",
+				},
+				"wait": {
+					Type:        jsonschema.Boolean,
+					Description: "Wait until the desired replicas are ready. Set it to true when the user asks to wait.",
+				},
+			},
+			Required: []string{"resource", "resource_name", "replicas"},
+		},
+	}
+
+	t10 := rolloutTool("rolloutRestart", "Restart the pods of a workload by rolling update, as kubectl rollout restart", nil)
+	t11 := rolloutTool("rolloutStatus", "Wait for the rollout of a workload to finish & show its progress, as kubectl rollout status",
+		map[string]jsonschema.Definition{
+			"timeout": {
+				Type:        jsonschema.String,
+				Description: "How long to wait for the rollout, e.g. 30s, 5m. 3m by default.",
+			},
+		})
+	t12 := rolloutTool("rolloutHistory", "Show the revisions of a workload with change causes & images, as kubectl rollout history", nil)
+	t13 := rolloutTool("rolloutUndo", `Roll back a workload to a previous revision, as kubectl rollout undo.
+Check rolloutHistory first if the user refers to a version by image or change cause.`,
+		map[string]jsonschema.Definition{
+			"to_revision": {
+				Type:        jsonschema.Integer,
+				Description: "The revision to roll back to. If not given, the previous revision is used.",
+			},
+		})
+
+	t14 := utils.Tool{
+		Name: "execCommand",
+		Description: `Run a diagnostic command in a container of a pod, or of a running pod of a workload, as kubectl exec.
+Only these commands are allowed: ` + strings.Join(funcs.ExecAllowlist, ", ") + `. hostname, date & commands running other commands like env take no arguments. No shell is involved, so pipes & redirects don't work.`,
+		Parameters: jsonschema.Definition{
+			Type: jsonschema.Object,
+			Properties: map[string]jsonschema.Definition{
+				"namespace": {
+					Type:        jsonschema.String,
+					Description: "The namespace where the pod is. If not given, the current namespace is used.",
+				},
+				"resource": {
+					Type:        jsonschema.String,
+					Description: "Resource type of resource_name, pods by default. For example: pods, deployment, statefulset",
+				},
+				"resource_name": {
+					Type:        jsonschema.String,
+					Description: "The name of the pod or workload",
+				},
+				"container": {
+					Type:        jsonschema.String,
+					Description: "The container to run the command in. If not given, the default container of the pod is used.",
+				},
+				"command": {
+					Type:        jsonschema.Array,
+					Items:       &jsonschema.Definition{Type: jsonschema.String},
+					Description: `The command & its arguments. For example: ["cat", "/etc/resolv.conf"], ["nslookup", "kubernetes.default"]`,
+				},
+			},
+			Required: []string{"resource_name", "command"},
+		},
+	}
+
+	t15 := utils.Tool{
+		Name: "portForward",
+		Description: `Forward local ports to a ready pod of a pod, service or workload in background, as kubectl port-forward.
+It keeps running until the user stops it by "/stop <id>".`,
+		Parameters: jsonschema.Definition{
+			Type: jsonschema.Object,
+			Properties: map[string]jsonschema.Definition{
+				"namespace": {
+					Type:        jsonschema.String,
+					Description: "The namespace where resource is. If not given, the current namespace is used.",
+				},
+				"resource": {
+					Type:        jsonschema.String,
+					Description: "Resource type of resource_name, pods by default. For example: pods, service, deployment",
+				},
+				"resource_name": {
+					Type:        jsonschema.String,
+					Description: "The name of the pod, service or workload",
+				},
+				"ports": {
+					Type:  jsonschema.Array,
+					Items: &jsonschema.Definition{Type: jsonschema.String},
+					Description: `Ports to forward as "local:remote", "remote" for the same local port, or ":remote" for a random local port.
+Remote ports of services are service ports. For example: ["3000:80"], ["8080"]`,
+				},
+			},
+			Required: []string{"resource_name", "ports"},
+		},
+	}
+
+	ts := []utils.Tool{t1, t2, t3, t4, t5, t6, t7, t8, t9, t10, t11, t12, t13, t14, t15}
+	if !readOnly {
+		return ts
+	}
+	// don't even offer the functions changing the cluster in read-only mode
+	var readOnlyTools []utils.Tool
+	for _, t := range ts {
+		if !mutatingFuncs[t.Name] {
+			readOnlyTools = append(readOnlyTools, t)
+		}
+	}
+	return readOnlyTools
+}
+
+// rolloutTool builds tools of rollout, which address deployments, statefulsets & daemonsets alike.
+func rolloutTool(name, description string, extra map[string]jsonschema.Definition) utils.Tool {
+	properties := map[string]jsonschema.Definition{
+		"namespace": {
+			Type:        jsonschema.String,
+			Description: "The namespace where resource is. If not given, the current namespace is used.",
+		},
+		"resource": {
+			Type:        jsonschema.String,
+			Enum:        []string{"deployments", "statefulsets", "daemonsets"},
+			Description: "Resource type of the workload",
+		},
+		"resource_name": {
+			Type:        jsonschema.String,
+			Description: "The name of the workload",
+		},
+	}
+	for k, v := range extra {
+		properties[k] = v
+	}
+	return utils.Tool{
+		Name:        name,
+		Description: description,
+		Parameters: jsonschema.Definition{
+			Type:       jsonschema.Object,
+			Properties: properties,
+			Required:   []string{"resource", "resource_name"},
+		},
+	}
 }
@@ -0,0 +1,210 @@
+/*
+Copyright © 2024 KokoiRuby kokoiruby@gmail.com
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"github.com/KokoiRuby/k8s-copilot/cmd/utils"
+	"strings"
+	"testing"
+)
+
+// fakeLLM replies with the given messages in turn & records the dialogues it receives.
+type fakeLLM struct {
+	model     string
+	replies   []utils.Message
+	dialogues [][]utils.Message
+}
+
+func (f *fakeLLM) Chat(ctx context.Context, messages []utils.Message, tools []utils.Tool) (utils.Message, error) {
+	f.dialogues = append(f.dialogues, append([]utils.Message(nil), messages...))
+	if len(f.replies) == 0 {
+		return utils.Message{}, errors.New("no more replies")
+	}
+	reply := f.replies[0]
+	if len(f.replies) > 1 {
+		f.replies = f.replies[1:]
+	}
+	return reply, nil
+}
+
+func (f *fakeLLM) SendMessage(ctx context.Context, prompt, input string) (string, error) {
+	return "", nil
+}
+
+func (f *fakeLLM) Model() string {
+	return f.model
+}
+
+func (f *fakeLLM) SetModel(model string) {
+	f.model = model
+}
+
+// fakeStreamLLM streams the content of replies in words.
+type fakeStreamLLM struct {
+	*fakeLLM
+}
+
+func (f fakeStreamLLM) ChatStream(ctx context.Context, messages []utils.Message, tools []utils.Tool, onContent func(string)) (utils.Message, error) {
+	msg, err := f.Chat(ctx, messages, tools)
+	for _, word := range strings.SplitAfter(msg.Content, " ") {
+		if word != "" {
+			onContent(word)
+		}
+	}
+	return msg, err
+}
+
+// fakeInvoke swaps the functions with fakes for the test.
+func fakeInvoke(t *testing.T, fn func(name, args string) (string, error)) {
+	t.Helper()
+	origin := invoke
+	t.Cleanup(func() { invoke = origin })
+	invoke = func(ctx context.Context, client utils.LLM, name, args string) (string, error) {
+		return fn(name, args)
+	}
+}
+
+func setMaxSteps(t *testing.T, steps int) {
+	t.Helper()
+	origin := maxSteps
+	t.Cleanup(func() { maxSteps = origin })
+	maxSteps = steps
+}
+
+func TestFuncCallingToolResults(t *testing.T) {
+	setMaxSteps(t, 10)
+	fakeInvoke(t, func(name, args string) (string, error) {
+		switch name {
+		case "listResource":
+			return "nginx", nil
+		case "deleteResource":
+			return "", errors.New("forbidden")
+		default:
+			return "", nil
+		}
+	})
+	client := &fakeLLM{replies: []utils.Message{
+		{Role: utils.RoleAssistant, ToolCalls: []utils.ToolCall{
+			{ID: "call_1", Name: "listResource", Arguments: `{}`},
+			{ID: "call_2", Name: "deleteResource", Arguments: `{}`},
+			{ID: "call_3", Name: "updateResource", Arguments: `{}`},
+		}},
+		{Role: utils.RoleAssistant, Content: "Deleting nginx is forbidden."},
+	}}
+
+	got := funcCalling(context.Background(), "delete nginx", newHistory(100000), client, client)
+	if got != "Deleting nginx is forbidden." {
+		t.Errorf("answer = %q", got)
+	}
+	if len(client.dialogues) != 2 {
+		t.Fatalf("model is called %d times, want 2", len(client.dialogues))
+	}
+
+	// system, user, assistant, then a tool result of each call
+	dialogue := client.dialogues[1]
+	if len(dialogue) != 6 {
+		t.Fatalf("dialogue has %d messages, want 6", len(dialogue))
+	}
+	want := []utils.Message{
+		{Role: utils.RoleTool, Content: "nginx", ToolCallID: "call_1"},
+		{Role: utils.RoleTool, Content: "Error: forbidden", ToolCallID: "call_2"},
+		{Role: utils.RoleTool, Content: "(empty)", ToolCallID: "call_3"},
+	}
+	for i, msg := range dialogue[3:] {
+		if msg.Role != want[i].Role || msg.Content != want[i].Content || msg.ToolCallID != want[i].ToolCallID {
+			t.Errorf("tool result %d = %+v, want %+v", i, msg, want[i])
+		}
+	}
+}
+
+func TestFuncCallingMaxSteps(t *testing.T) {
+	setMaxSteps(t, 3)
+	calls := 0
+	fakeInvoke(t, func(name, args string) (string, error) {
+		calls++
+		return "nginx", nil
+	})
+	client := &fakeLLM{replies: []utils.Message{
+		{Role: utils.RoleAssistant, ToolCalls: []utils.ToolCall{{ID: "call_1", Name: "listResource", Arguments: `{}`}}},
+	}}
+
+	got := funcCalling(context.Background(), "list pods forever", newHistory(100000), client, client)
+	if !strings.Contains(got, "No final answer after [3] steps") {
+		t.Errorf("answer = %q, want the max-steps message", got)
+	}
+	if len(client.dialogues) != 3 || calls != 3 {
+		t.Errorf("model is called %d times & functions %d times, want 3", len(client.dialogues), calls)
+	}
+}
+
+func TestHandleModelCommand(t *testing.T) {
+	originRouter, originGenerator := router, generator
+	t.Cleanup(func() { router, generator = originRouter, originGenerator })
+
+	tests := []struct {
+		input         string
+		wantRouter    string
+		wantGenerator string
+	}{
+		{"/model", "router", "generator"},
+		{"/model gpt-4o", "gpt-4o", "generator"},
+		{"/model gen gpt-4o", "router", "gpt-4o"},
+		{"/model  gen   o1-mini ", "router", "o1-mini"},
+		{"/model gen", "router", "generator"},
+		{"/model gpt-4o o1-mini", "router", "generator"},
+		{"/model gen gpt-4o o1-mini", "router", "generator"},
+	}
+	for _, tt := range tests {
+		r, g := &fakeLLM{model: "router"}, &fakeLLM{model: "generator"}
+		router, generator = r, g
+		handleCommand(tt.input, newHistory(100000))
+		if r.model != tt.wantRouter || g.model != tt.wantGenerator {
+			t.Errorf("%q switched models to [%s] & [%s], want [%s] & [%s]", tt.input, r.model, g.model, tt.wantRouter, tt.wantGenerator)
+		}
+	}
+}
+
+func TestFuncCallingStream(t *testing.T) {
+	setMaxSteps(t, 10)
+	origin := stream
+	t.Cleanup(func() { stream = origin })
+	stream = true
+
+	tests := []struct {
+		name  string
+		reply string
+		want  string
+	}{
+		// the answer is printed as it's streamed
+		{"streamed", "No pods found.", ""},
+		{"empty", "", "No answer from the model, try to refine your query."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fakeStreamLLM{&fakeLLM{replies: []utils.Message{{Role: utils.RoleAssistant, Content: tt.reply}}}}
+			session := newHistory(100000)
+			got := funcCalling(context.Background(), "list pods", session, client, client)
+			if got != tt.want {
+				t.Errorf("answer = %q, want %q", got, tt.want)
+			}
+			// the empty answer is not remembered
+			want := utils.Message{Role: utils.RoleAssistant, Content: tt.reply}
+			if tt.reply == "" {
+				want = utils.Message{Role: utils.RoleUser, Content: "list pods"}
+			}
+			if last := session.messages[len(session.messages)-1]; last.Role != want.Role || last.Content != want.Content {
+				t.Errorf("last remembered message = %+v, want %+v", last, want)
+			}
+		})
+	}
+}
+
+func TestInvokeFuncScaleWithoutReplicas(t *testing.T) {
+	_, err := invokeFunc(context.Background(), &fakeLLM{}, "scaleResource", `{"resource":"deploy","resource_name":"web"}`)
+	if err == nil || !strings.Contains(err.Error(), "replicas must be given") {
+		t.Errorf("invokeFunc() error = %v, want replicas must be given", err)
+	}
+}
@@ -0,0 +1,101 @@
+package funcs
+
+import (
+	"context"
+	"fmt"
+	"github.com/KokoiRuby/k8s-copilot/cmd/utils"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"strings"
+)
+
+// FieldManager owns the fields changed by the copilot, shown in managedFields.
+const FieldManager = "k8s-copilot"
+
+// ApplyResource creates or updates the resource described by input via server-side apply,
+// so it's idempotent & the applied fields are owned by the copilot.
+func ApplyResource(ctx context.Context, client utils.LLM, input string, force bool, clientGo *utils.ClientGo) (string, error) {
+	// generate YAML manifest given user input
+	yml, err := client.SendMessage(ctx, CreatePrompt, input)
+	if err != nil {
+		return "", err
+	}
+
+	// apply every object of manifests in dependency order
+	return handleManifests(yml, clientGo, func(ri dynamic.ResourceInterface, obj *unstructured.Unstructured) (string, error) {
+		return applyWithPreview(ctx, ri, obj, force)
+	})
+}
+
+// applyWithPreview previews server-side apply by dry-run, as a diff against the live object if it exists
+// or the manifest otherwise, then applies the object after approval.
+func applyWithPreview(ctx context.Context, ri dynamic.ResourceInterface, obj *unstructured.Unstructured, force bool) (string, error) {
+	// fields owned by server are not meant to be applied
+	obj = obj.DeepCopy()
+	obj.SetResourceVersion("")
+	obj.SetManagedFields(nil)
+	unstructured.RemoveNestedField(obj.Object, "status")
+
+	opts := metav1.ApplyOptions{FieldManager: FieldManager, Force: force, DryRun: dryRunAll}
+	dryRunObj, err := ri.Apply(ctx, obj.GetName(), obj, opts)
+	if err != nil {
+		return "", applyError(obj.GetName(), err)
+	}
+
+	live, err := ri.Get(ctx, obj.GetName(), metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		manifest, err := toYAML(obj)
+		if err != nil {
+			return "", err
+		}
+		fmt.Printf("Manifest of resource [%s] to create:\n%s", obj.GetName(), manifest)
+	case err != nil:
+		return "", err
+	default:
+		d, err := diff(live, dryRunObj)
+		if err != nil {
+			return "", err
+		}
+		if d == "" {
+			return fmt.Sprintf("Resource [%s] is unchanged", obj.GetName()), nil
+		}
+		fmt.Printf("Diff of resource [%s]:\n%s", obj.GetName(), d)
+	}
+	if DryRun {
+		return fmt.Sprintf("Dry run: resource [%s] would be applied, nothing is applied", obj.GetName()), nil
+	}
+
+	ok, err := confirm(fmt.Sprintf("Are you sure that you want to apply the resource [%s]?", obj.GetName()))
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "Apply aborted by user.", nil
+	}
+	opts.DryRun = nil
+	_, err = ri.Apply(ctx, obj.GetName(), obj, opts)
+	if err != nil {
+		return "", applyError(obj.GetName(), err)
+	}
+	return fmt.Sprintf("Resource [%s] applied successfully", obj.GetName()), nil
+}
+
+// applyError explains the conflicts with other field managers, which could be overridden by force.
+func applyError(name string, err error) error {
+	if !apierrors.IsConflict(err) {
+		return err
+	}
+	statusErr, ok := err.(apierrors.APIStatus)
+	if !ok || statusErr.Status().Details == nil {
+		return err
+	}
+	var conflicts []string
+	for _, cause := range statusErr.Status().Details.Causes {
+		conflicts = append(conflicts, fmt.Sprintf("%s: %s", cause.Field, cause.Message))
+	}
+	return fmt.Errorf("applying resource [%s] conflicts with other field managers, set force to take over these fields:\n%s",
+		name, strings.Join(conflicts, "\n"))
+}
@@ -0,0 +1,37 @@
+package funcs
+
+import (
+	"errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"testing"
+)
+
+func TestApplyError(t *testing.T) {
+	conflict := &apierrors.StatusError{ErrStatus: metav1.Status{
+		Status: metav1.StatusFailure,
+		Code:   409,
+		Reason: metav1.StatusReasonConflict,
+		Details: &metav1.StatusDetails{Causes: []metav1.StatusCause{
+			{Type: metav1.CauseTypeFieldManagerConflict, Field: ".spec.replicas", Message: `conflict with "kubectl"`},
+			{Type: metav1.CauseTypeFieldManagerConflict, Field: ".spec.template.spec.containers[name=\"web\"].image", Message: `conflict with "helm"`},
+		}},
+	}}
+	want := `applying resource [web] conflicts with other field managers, set force to take over these fields:
+.spec.replicas: conflict with "kubectl"
+.spec.template.spec.containers[name="web"].image: conflict with "helm"`
+	if got := applyError("web", conflict); got == nil || got.Error() != want {
+		t.Errorf("applyError() =\n%v\nwant\n%s", got, want)
+	}
+
+	// other errors are kept as they are
+	notFound := apierrors.NewNotFound(schema.GroupResource{Group: "apps", Resource: "deployments"}, "web")
+	if got := applyError("web", notFound); got != error(notFound) {
+		t.Errorf("applyError() = %v, want %v", got, notFound)
+	}
+	other := errors.New("connection refused")
+	if got := applyError("web", other); got != other {
+		t.Errorf("applyError() = %v, want %v", got, other)
+	}
+}
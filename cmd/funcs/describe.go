@@ -0,0 +1,267 @@
+package funcs
+
+import (
+	"context"
+	"fmt"
+	"github.com/KokoiRuby/k8s-copilot/cmd/utils"
+	"io"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// lastAppliedAnnotation is left out from annotations, it repeats the whole manifest.
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// DescribeResource describes the object as kubectl describe does, with spec highlights, status conditions,
+// owner references & related events.
+func DescribeResource(ctx context.Context, namespace, resource, resourceName string, clientGo *utils.ClientGo) (string, error) {
+	res, err := clientGo.ResolveResource(resource)
+	if err != nil {
+		return "", err
+	}
+	ri, err := clientGo.ResourceInterface(res, namespace)
+	if err != nil {
+		return "", err
+	}
+	obj, err := ri.Get(ctx, resourceName, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	describeMetadata(w, obj)
+	describeSpec(w, obj)
+	describeConditions(w, obj)
+	describeEvents(ctx, w, obj, clientGo)
+	_ = w.Flush()
+
+	result := b.String()
+	fmt.Print(result)
+	return result, nil
+}
+
+func describeMetadata(w io.Writer, obj *unstructured.Unstructured) {
+	fmt.Fprintf(w, "Name:\t%s\n", obj.GetName())
+	if obj.GetNamespace() != "" {
+		fmt.Fprintf(w, "Namespace:\t%s\n", obj.GetNamespace())
+	}
+	fmt.Fprintf(w, "Kind:\t%s (%s)\n", obj.GetKind(), obj.GetAPIVersion())
+	fmt.Fprintf(w, "Created:\t%s (%s ago)\n", obj.GetCreationTimestamp().UTC().Format("2006-01-02T15:04:05Z"), age(obj.GetCreationTimestamp().Time))
+
+	annotations := obj.GetAnnotations()
+	delete(annotations, lastAppliedAnnotation)
+	describeMap(w, "Labels", obj.GetLabels())
+	describeMap(w, "Annotations", annotations)
+
+	if owners := obj.GetOwnerReferences(); len(owners) > 0 {
+		fmt.Fprint(w, "Owner References:")
+		for _, owner := range owners {
+			controller := ""
+			if owner.Controller != nil && *owner.Controller {
+				controller = " (controller)"
+			}
+			fmt.Fprintf(w, "\t%s/%s%s\n", owner.Kind, owner.Name, controller)
+		}
+	}
+}
+
+// describeMap prints the map as key=value, one per line & sorted by key.
+func describeMap(w io.Writer, name string, m map[string]string) {
+	fmt.Fprintf(w, "%s:", name)
+	if len(m) == 0 {
+		fmt.Fprint(w, "\t<none>\n")
+		return
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, "\t%s=%s\n", k, m[k])
+	}
+}
+
+// describeSpec prints the highlights of spec: scalar fields, selector, strategy, ports & containers of pod template.
+func describeSpec(w io.Writer, obj *unstructured.Unstructured) {
+	spec, ok, _ := unstructured.NestedMap(obj.Object, "spec")
+	if !ok {
+		return
+	}
+	fmt.Fprintln(w, "Spec:")
+	keys := make([]string, 0, len(spec))
+	for k := range spec {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		switch v := spec[k].(type) {
+		case string, bool, int64, float64:
+			fmt.Fprintf(w, "  %s:\t%v\n", k, v)
+		}
+	}
+
+	if selector := specSelector(spec); selector != "" {
+		fmt.Fprintf(w, "  selector:\t%s\n", selector)
+	}
+	if strategy, ok, _ := unstructured.NestedString(spec, "strategy", "type"); ok {
+		fmt.Fprintf(w, "  strategy:\t%s\n", strategy)
+	}
+	if ports, ok, _ := unstructured.NestedSlice(spec, "ports"); ok {
+		fmt.Fprintln(w, "  ports:")
+		for _, p := range ports {
+			if port, ok := p.(map[string]interface{}); ok {
+				fmt.Fprintf(w, "    %s\n", formatPort(port))
+			}
+		}
+	}
+
+	// pods, workloads & cronjobs
+	for _, path := range [][]string{{"containers"}, {"template", "spec", "containers"}, {"jobTemplate", "spec", "template", "spec", "containers"}} {
+		containers, ok, _ := unstructured.NestedSlice(spec, path...)
+		if !ok {
+			continue
+		}
+		fmt.Fprintln(w, "  containers:")
+		for _, c := range containers {
+			container, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _, _ := unstructured.NestedString(container, "name")
+			image, _, _ := unstructured.NestedString(container, "image")
+			fmt.Fprintf(w, "    %s:\n", name)
+			fmt.Fprintf(w, "      image:\t%s\n", image)
+			if ports, ok, _ := unstructured.NestedSlice(container, "ports"); ok {
+				var formatted []string
+				for _, p := range ports {
+					if port, ok := p.(map[string]interface{}); ok {
+						formatted = append(formatted, formatPort(port))
+					}
+				}
+				fmt.Fprintf(w, "      ports:\t%s\n", strings.Join(formatted, ", "))
+			}
+			for _, kind := range []string{"requests", "limits"} {
+				if quantities, ok, _ := unstructured.NestedStringMap(container, "resources", kind); ok && len(quantities) > 0 {
+					fmt.Fprintf(w, "      %s:\t%s\n", kind, joinMap(quantities))
+				}
+			}
+		}
+		break
+	}
+}
+
+// specSelector gets the label selector of workloads or services.
+func specSelector(spec map[string]interface{}) string {
+	if matchLabels, ok, _ := unstructured.NestedStringMap(spec, "selector", "matchLabels"); ok {
+		return joinMap(matchLabels)
+	}
+	if selector, ok, _ := unstructured.NestedStringMap(spec, "selector"); ok {
+		return joinMap(selector)
+	}
+	return ""
+}
+
+// formatPort formats ports of services & containers, e.g. http 80/TCP -> 8080.
+func formatPort(port map[string]interface{}) string {
+	var s string
+	if name, ok := port["name"].(string); ok {
+		s = name + " "
+	}
+	if p, ok := port["port"]; ok {
+		s += fmt.Sprint(p)
+	} else {
+		s += fmt.Sprint(port["containerPort"])
+	}
+	protocol, ok := port["protocol"].(string)
+	if !ok {
+		protocol = "TCP"
+	}
+	s += "/" + protocol
+	if target, ok := port["targetPort"]; ok {
+		s += fmt.Sprintf(" -> %v", target)
+	}
+	return s
+}
+
+func joinMap(m map[string]string) string {
+	pairs := make([]string, 0, len(m))
+	for k, v := range m {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// describeConditions prints status conditions as a table.
+func describeConditions(w io.Writer, obj *unstructured.Unstructured) {
+	conditions, ok, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	if !ok || len(conditions) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "Conditions:")
+	fmt.Fprintln(w, "  TYPE\tSTATUS\tREASON\tAGE\tMESSAGE")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		field := func(name string) string {
+			if v, ok := condition[name].(string); ok && v != "" {
+				return v
+			}
+			return "<none>"
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n", field("type"), field("status"), field("reason"),
+			formatCell(metav1.TableColumnDefinition{Format: "date"}, condition["lastTransitionTime"]), field("message"))
+	}
+}
+
+// describeEvents prints events involving the object, oldest first. Events are optional, failing to list them is not fatal.
+func describeEvents(ctx context.Context, w io.Writer, obj *unstructured.Unstructured, clientGo *utils.ClientGo) {
+	events, err := clientGo.ClientSet.CoreV1().Events(obj.GetNamespace()).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("involvedObject.uid", string(obj.GetUID())).String(),
+	})
+	if err != nil {
+		fmt.Fprintf(w, "Events:\t<unable to list: %v>\n", err)
+		return
+	}
+	if len(events.Items) == 0 {
+		fmt.Fprint(w, "Events:\t<none>\n")
+		return
+	}
+	sort.SliceStable(events.Items, func(i, j int) bool {
+		return eventTime(events.Items[i]).Time.Before(eventTime(events.Items[j]).Time)
+	})
+	fmt.Fprintln(w, "Events:")
+	fmt.Fprintln(w, "  TYPE\tREASON\tAGE\tFROM\tMESSAGE")
+	for _, e := range events.Items {
+		last := age(eventTime(e).Time)
+		if e.Count > 1 && !e.FirstTimestamp.IsZero() {
+			last = fmt.Sprintf("%s (x%d over %s)", last, e.Count, age(e.FirstTimestamp.Time))
+		}
+		from := e.Source.Component
+		if from == "" {
+			from = e.ReportingController
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n", e.Type, e.Reason, last, from, strings.TrimSpace(e.Message))
+	}
+}
+
+// eventTime is the last time the event occurred.
+func eventTime(e corev1.Event) metav1.Time {
+	switch {
+	case !e.LastTimestamp.IsZero():
+		return e.LastTimestamp
+	case !e.EventTime.IsZero():
+		return metav1.NewTime(e.EventTime.Time)
+	default:
+		return e.CreationTimestamp
+	}
+}
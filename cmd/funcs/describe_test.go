@@ -0,0 +1,148 @@
+package funcs
+
+import (
+	"bytes"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"testing"
+	"text/tabwriter"
+	"time"
+)
+
+func TestFormatPort(t *testing.T) {
+	tests := []struct {
+		port map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{"name": "http", "port": int64(80), "protocol": "TCP", "targetPort": int64(8080)}, "http 80/TCP -> 8080"},
+		{map[string]interface{}{"port": int64(53), "protocol": "UDP", "targetPort": "dns"}, "53/UDP -> dns"},
+		{map[string]interface{}{"containerPort": int64(8080)}, "8080/TCP"},
+	}
+	for _, tt := range tests {
+		if got := formatPort(tt.port); got != tt.want {
+			t.Errorf("formatPort(%v) = %q, want %q", tt.port, got, tt.want)
+		}
+	}
+}
+
+func TestSpecSelector(t *testing.T) {
+	tests := []struct {
+		name string
+		spec map[string]interface{}
+		want string
+	}{
+		{
+			name: "workload",
+			spec: map[string]interface{}{"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"tier": "web", "app": "nginx"}}},
+			want: "app=nginx,tier=web",
+		},
+		{
+			name: "service",
+			spec: map[string]interface{}{"selector": map[string]interface{}{"app": "nginx"}},
+			want: "app=nginx",
+		},
+		{
+			name: "none",
+			spec: map[string]interface{}{"replicas": int64(1)},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := specSelector(tt.spec); got != tt.want {
+				t.Errorf("specSelector() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDescribeSpec(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": int64(3),
+			"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "nginx"}},
+			"strategy": map[string]interface{}{"type": "RollingUpdate"},
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name":      "nginx",
+							"image":     "nginx:1.27",
+							"ports":     []interface{}{map[string]interface{}{"containerPort": int64(80)}},
+							"resources": map[string]interface{}{"limits": map[string]interface{}{"memory": "128Mi", "cpu": "500m"}},
+						},
+					},
+				},
+			},
+		},
+	}}
+	want := `Spec:
+  replicas:  3
+  selector:  app=nginx
+  strategy:  RollingUpdate
+  containers:
+    nginx:
+      image:   nginx:1.27
+      ports:   80/TCP
+      limits:  cpu=500m,memory=128Mi
+`
+	var b bytes.Buffer
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	describeSpec(w, obj)
+	_ = w.Flush()
+	if got := b.String(); got != want {
+		t.Errorf("describeSpec() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestDescribeConditions(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{
+					"type":               "Available",
+					"status":             "True",
+					"reason":             "MinimumReplicasAvailable",
+					"lastTransitionTime": time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339),
+					"message":            "Deployment has minimum availability.",
+				},
+				map[string]interface{}{"type": "Progressing", "status": "Unknown"},
+			},
+		},
+	}}
+	want := `Conditions:
+  TYPE         STATUS   REASON                    AGE     MESSAGE
+  Available    True     MinimumReplicasAvailable  120m    Deployment has minimum availability.
+  Progressing  Unknown  <none>                    <none>  <none>
+`
+	var b bytes.Buffer
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	describeConditions(w, obj)
+	_ = w.Flush()
+	if got := b.String(); got != want {
+		t.Errorf("describeConditions() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestEventTime(t *testing.T) {
+	created := metav1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	first := metav1.NewTime(created.Add(time.Minute))
+	last := metav1.NewTime(created.Add(time.Hour))
+	tests := []struct {
+		name  string
+		event corev1.Event
+		want  metav1.Time
+	}{
+		{"last timestamp", corev1.Event{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: created}, LastTimestamp: last, EventTime: metav1.NewMicroTime(first.Time)}, last},
+		{"event time", corev1.Event{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: created}, EventTime: metav1.NewMicroTime(first.Time)}, first},
+		{"creation", corev1.Event{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: created}}, created},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := eventTime(tt.event); !got.Equal(&tt.want) {
+				t.Errorf("eventTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package funcs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/KokoiRuby/k8s-copilot/cmd/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/util/exec"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ExecAllowlist are the commands allowed to run in containers, matched by the first word of command exactly.
+var ExecAllowlist = []string{"printenv", "cat", "ls", "ps", "df", "hostname", "id", "date", "nslookup", "dig", "getent"}
+
+var (
+	// runnerCommands run another command given by their arguments, which would bypass the allowlist.
+	runnerCommands = []string{"env", "nice", "nohup", "timeout", "xargs", "setsid", "stdbuf", "chroot", "busybox", "sudo", "su", "watch", "strace", "sh", "bash"}
+	// noArgCommands change the container if given arguments, e.g. hostname NAME or date -s TIME.
+	noArgCommands = []string{"hostname", "date"}
+)
+
+const (
+	// execTimeout stops commands running too long.
+	execTimeout = 30 * time.Second
+	// execLimitBytes caps the output of commands handed to the model.
+	execLimitBytes = 16 * 1024
+)
+
+// ExecCommand runs the allowed command in a container of the pod, or of a running pod of the workload.
+// It's always confirmed by the user, even if confirmation is turned off.
+func ExecCommand(ctx context.Context, namespace, resource, resourceName, container string, command []string, clientGo *utils.ClientGo) (string, error) {
+	if len(command) == 0 {
+		return "", fmt.Errorf("command must not be empty")
+	}
+	if err := allowedCommand(command); err != nil {
+		return "", err
+	}
+	if resource == "" {
+		resource = "pods"
+	}
+	res, err := clientGo.ResolveResource(resource)
+	if err != nil {
+		return "", err
+	}
+	namespace, err = clientGo.NamespaceFor(res, namespace)
+	if err != nil {
+		return "", err
+	}
+	pods, err := workloadPods(ctx, res, namespace, resourceName, clientGo)
+	if err != nil {
+		return "", err
+	}
+	i := slices.IndexFunc(pods, func(pod corev1.Pod) bool {
+		return pod.Status.Phase == corev1.PodRunning
+	})
+	if i < 0 {
+		return "", fmt.Errorf("no running pod found for resource [%s]", resourceName)
+	}
+	pod := pods[i]
+	if container == "" {
+		container = defaultContainer(pod)
+	}
+
+	cmdline := strings.Join(command, " ")
+	if DryRun {
+		return fmt.Sprintf("Dry run: command [%s] would be run in pod [%s] container [%s], nothing is run", cmdline, pod.Name, container), nil
+	}
+	ok, err := ask(fmt.Sprintf("Are you sure that you want to run command [%s] in pod [%s] container [%s]?", cmdline, pod.Name, container))
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "Exec aborted by user.", nil
+	}
+
+	req := clientGo.ClientSet.CoreV1().RESTClient().Post().
+		Resource("pods").Namespace(pod.Namespace).Name(pod.Name).SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{Container: container, Command: command, Stdout: true, Stderr: true}, scheme.ParameterCodec)
+	executor, err := newExecutor(clientGo.Config, req.URL())
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, execTimeout)
+	defer cancel()
+	out := &limitWriter{limit: execLimitBytes}
+	err = executor.StreamWithContext(ctx, remotecommand.StreamOptions{Stdout: out, Stderr: out})
+
+	result := out.String()
+	if out.truncated {
+		result += fmt.Sprintf("\n... (truncated to the first %d bytes)", execLimitBytes)
+	}
+	var exitErr exec.ExitError
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		result += fmt.Sprintf("\n(timed out after %s)", execTimeout)
+	case errors.As(err, &exitErr):
+		result += fmt.Sprintf("\n(exit code %d)", exitErr.ExitStatus())
+	case err != nil:
+		return "", err
+	}
+	fmt.Println(result)
+	return result, nil
+}
+
+// allowedCommand checks the command against the allowlist, commands running others or changing the container are only allowed without arguments.
+func allowedCommand(command []string) error {
+	name := command[0]
+	if !slices.Contains(ExecAllowlist, name) {
+		return fmt.Errorf("command [%s] not allowed, allowed: %s", name, strings.Join(ExecAllowlist, ", "))
+	}
+	if len(command) > 1 && (slices.Contains(runnerCommands, name) || slices.Contains(noArgCommands, name)) {
+		return fmt.Errorf("command [%s] is only allowed without arguments", name)
+	}
+	return nil
+}
+
+// newExecutor streams over websocket, falling back to SPDY for API servers not supporting it, as kubectl exec does.
+func newExecutor(config *rest.Config, u *url.URL) (remotecommand.Executor, error) {
+	spdyExecutor, err := remotecommand.NewSPDYExecutor(config, "POST", u)
+	if err != nil {
+		return nil, err
+	}
+	websocketExecutor, err := remotecommand.NewWebSocketExecutor(config, "GET", u.String())
+	if err != nil {
+		return nil, err
+	}
+	return remotecommand.NewFallbackExecutor(websocketExecutor, spdyExecutor, func(err error) bool {
+		return httpstream.IsUpgradeFailure(err) || httpstream.IsHTTPSProxyError(err)
+	})
+}
+
+// limitWriter keeps the first limit bytes written, stdout & stderr may write concurrently.
+type limitWriter struct {
+	mu        sync.Mutex
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (w *limitWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if room := w.limit - w.buf.Len(); len(p) > room {
+		w.buf.Write(p[:max(room, 0)])
+		w.truncated = true
+	} else {
+		w.buf.Write(p)
+	}
+	// pretend everything is written to keep the command running
+	return len(p), nil
+}
+
+func (w *limitWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
@@ -0,0 +1,32 @@
+package funcs
+
+import (
+	"testing"
+)
+
+func TestAllowedCommand(t *testing.T) {
+	allowlist := ExecAllowlist
+	defer func() { ExecAllowlist = allowlist }()
+	ExecAllowlist = []string{"cat", "env", "date", "hostname"}
+
+	tests := []struct {
+		command []string
+		allowed bool
+	}{
+		{[]string{"cat", "/etc/resolv.conf"}, true},
+		{[]string{"env"}, true},
+		{[]string{"date"}, true},
+		{[]string{"hostname"}, true},
+		{[]string{"env", "sh", "-c", "rm -rf /"}, false},
+		{[]string{"date", "-s", "2000-01-01"}, false},
+		{[]string{"hostname", "evil"}, false},
+		{[]string{"rm", "-rf", "/"}, false},
+		{[]string{"printenv"}, false},
+	}
+	for _, tt := range tests {
+		err := allowedCommand(tt.command)
+		if (err == nil) != tt.allowed {
+			t.Errorf("allowedCommand(%q) = %v, want allowed %v", tt.command, err, tt.allowed)
+		}
+	}
+}
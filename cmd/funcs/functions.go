@@ -4,282 +4,225 @@ import (
 	"context"
 	"fmt"
 	"github.com/KokoiRuby/k8s-copilot/cmd/utils"
-	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes/scheme"
-	"k8s.io/client-go/restmapper"
 )
 
-type Resource struct {
-	GVR        schema.GroupVersionResource
-	Namespaced bool
-}
-
-var resourceMap = map[string]Resource{
-	"pods":                              {GVR: schema.GroupVersionResource{Group: "", Version: "v1", Resource: "pods"}, Namespaced: true},
-	"services":                          {GVR: schema.GroupVersionResource{Group: "", Version: "v1", Resource: "services"}, Namespaced: true},
-	"deployments":                       {GVR: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, Namespaced: true},
-	"namespaces":                        {GVR: schema.GroupVersionResource{Group: "", Version: "v1", Resource: "namespaces"}, Namespaced: false},
-	"bindings":                          {GVR: schema.GroupVersionResource{Group: "", Version: "v1", Resource: "bindings"}, Namespaced: true},
-	"componentstatuses":                 {GVR: schema.GroupVersionResource{Group: "", Version: "v1", Resource: "componentstatuses"}, Namespaced: false},
-	"configmaps":                        {GVR: schema.GroupVersionResource{Group: "", Version: "v1", Resource: "configmaps"}, Namespaced: true},
-	"endpoints":                         {GVR: schema.GroupVersionResource{Group: "", Version: "v1", Resource: "endpoints"}, Namespaced: true},
-	"events":                            {GVR: schema.GroupVersionResource{Group: "", Version: "v1", Resource: "events"}, Namespaced: true},
-	"limitranges":                       {GVR: schema.GroupVersionResource{Group: "", Version: "v1", Resource: "limitranges"}, Namespaced: true},
-	"nodes":                             {GVR: schema.GroupVersionResource{Group: "", Version: "v1", Resource: "nodes"}, Namespaced: false},
-	"persistentvolumeclaims":            {GVR: schema.GroupVersionResource{Group: "", Version: "v1", Resource: "persistentvolumeclaims"}, Namespaced: true},
-	"persistentvolumes":                 {GVR: schema.GroupVersionResource{Group: "", Version: "v1", Resource: "persistentvolumes"}, Namespaced: false},
-	"podtemplates":                      {GVR: schema.GroupVersionResource{Group: "", Version: "v1", Resource: "podtemplates"}, Namespaced: true},
-	"replicationcontrollers":            {GVR: schema.GroupVersionResource{Group: "", Version: "v1", Resource: "replicationcontrollers"}, Namespaced: true},
-	"resourcequotas":                    {GVR: schema.GroupVersionResource{Group: "", Version: "v1", Resource: "resourcequotas"}, Namespaced: true},
-	"secrets":                           {GVR: schema.GroupVersionResource{Group: "", Version: "v1", Resource: "secrets"}, Namespaced: true},
-	"serviceaccounts":                   {GVR: schema.GroupVersionResource{Group: "", Version: "v1", Resource: "serviceaccounts"}, Namespaced: true},
-	"challenges":                        {GVR: schema.GroupVersionResource{Group: "acme.cert-manager.io", Version: "v1", Resource: "challenges"}, Namespaced: true},
-	"orders":                            {GVR: schema.GroupVersionResource{Group: "acme.cert-manager.io", Version: "v1", Resource: "orders"}, Namespaced: true},
-	"mutatingwebhookconfigurations":     {GVR: schema.GroupVersionResource{Group: "admissionregistration.k8s.io", Version: "v1", Resource: "mutatingwebhookconfigurations"}, Namespaced: false},
-	"validatingadmissionpolicies":       {GVR: schema.GroupVersionResource{Group: "admissionregistration.k8s.io", Version: "v1", Resource: "validatingadmissionpolicies"}, Namespaced: false},
-	"validatingadmissionpolicybindings": {GVR: schema.GroupVersionResource{Group: "admissionregistration.k8s.io", Version: "v1", Resource: "validatingadmissionpolicybindings"}, Namespaced: false},
-	"validatingwebhookconfigurations":   {GVR: schema.GroupVersionResource{Group: "admissionregistration.k8s.io", Version: "v1", Resource: "validatingwebhookconfigurations"}, Namespaced: false},
-	"customresourcedefinitions":         {GVR: schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}, Namespaced: false},
-	"apiservices":                       {GVR: schema.GroupVersionResource{Group: "apiregistration.k8s.io", Version: "v1", Resource: "apiservices"}, Namespaced: false},
-	"applications":                      {GVR: schema.GroupVersionResource{Group: "application.aiops.com", Version: "v1", Resource: "applications"}, Namespaced: true},
-	"controllerrevisions":               {GVR: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "controllerrevisions"}, Namespaced: true},
-	"daemonsets":                        {GVR: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "daemonsets"}, Namespaced: true},
-	"replicasets":                       {GVR: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"}, Namespaced: true},
-	"statefulsets":                      {GVR: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "statefulsets"}, Namespaced: true},
-	"selfsubjectreviews":                {GVR: schema.GroupVersionResource{Group: "authentication.k8s.io", Version: "v1", Resource: "selfsubjectreviews"}, Namespaced: false},
-	"tokenreviews":                      {GVR: schema.GroupVersionResource{Group: "authentication.k8s.io", Version: "v1", Resource: "tokenreviews"}, Namespaced: false},
-	"localsubjectaccessreviews":         {GVR: schema.GroupVersionResource{Group: "authorization.k8s.io", Version: "v1", Resource: "localsubjectaccessreviews"}, Namespaced: true},
-	"selfsubjectaccessreviews":          {GVR: schema.GroupVersionResource{Group: "authorization.k8s.io", Version: "v1", Resource: "selfsubjectaccessreviews"}, Namespaced: false},
-	"selfsubjectrulesreviews":           {GVR: schema.GroupVersionResource{Group: "authorization.k8s.io", Version: "v1", Resource: "selfsubjectrulesreviews"}, Namespaced: false},
-	"subjectaccessreviews":              {GVR: schema.GroupVersionResource{Group: "authorization.k8s.io", Version: "v1", Resource: "subjectaccessreviews"}, Namespaced: false},
-	"horizontalpodautoscalers":          {GVR: schema.GroupVersionResource{Group: "autoscaling", Version: "v2", Resource: "horizontalpodautoscalers"}, Namespaced: true},
-	"cronjobs":                          {GVR: schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "cronjobs"}, Namespaced: true},
-	"jobs":                              {GVR: schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"}, Namespaced: true},
-	"certificaterequests":               {GVR: schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificaterequests"}, Namespaced: true},
-	"certificates":                      {GVR: schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}, Namespaced: true},
-	"clusterissuers":                    {GVR: schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "clusterissuers"}, Namespaced: false},
-	"issuers":                           {GVR: schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "issuers"}, Namespaced: true},
-	"certificatesigningrequests":        {GVR: schema.GroupVersionResource{Group: "certificates.k8s.io", Version: "v1", Resource: "certificatesigningrequests"}, Namespaced: false},
-	"leases":                            {GVR: schema.GroupVersionResource{Group: "coordination.k8s.io", Version: "v1", Resource: "leases"}, Namespaced: true},
-	"endpointslices":                    {GVR: schema.GroupVersionResource{Group: "discovery.k8s.io", Version: "v1", Resource: "endpointslices"}, Namespaced: true},
-	"flowschemas":                       {GVR: schema.GroupVersionResource{Group: "flowcontrol.apiserver.k8s.io", Version: "v1", Resource: "flowschemas"}, Namespaced: false},
-	"prioritylevelconfigurations":       {GVR: schema.GroupVersionResource{Group: "flowcontrol.apiserver.k8s.io", Version: "v1", Resource: "prioritylevelconfigurations"}, Namespaced: false},
-	"ingressclasses":                    {GVR: schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingressclasses"}, Namespaced: false},
-	"ingresses":                         {GVR: schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"}, Namespaced: true},
-	"networkpolicies":                   {GVR: schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicies"}, Namespaced: true},
-	"runtimeclasses":                    {GVR: schema.GroupVersionResource{Group: "node.k8s.io", Version: "v1", Resource: "runtimeclasses"}, Namespaced: false},
-	"catalogsources":                    {GVR: schema.GroupVersionResource{Group: "operators.coreos.com", Version: "v1alpha1", Resource: "catalogsources"}, Namespaced: true},
-	"clusterserviceversions":            {GVR: schema.GroupVersionResource{Group: "operators.coreos.com", Version: "v1alpha1", Resource: "clusterserviceversions"}, Namespaced: true},
-	"installplans":                      {GVR: schema.GroupVersionResource{Group: "operators.coreos.com", Version: "v1alpha1", Resource: "installplans"}, Namespaced: true},
-	"olmconfigs":                        {GVR: schema.GroupVersionResource{Group: "operators.coreos.com", Version: "v1", Resource: "olmconfigs"}, Namespaced: false},
-	"operatorconditions":                {GVR: schema.GroupVersionResource{Group: "operators.coreos.com", Version: "v2", Resource: "operatorconditions"}, Namespaced: true},
-	"operatorgroups":                    {GVR: schema.GroupVersionResource{Group: "operators.coreos.com", Version: "v1", Resource: "operatorgroups"}, Namespaced: true},
-	"operators":                         {GVR: schema.GroupVersionResource{Group: "operators.coreos.com", Version: "v1", Resource: "operators"}, Namespaced: false},
-	"subscriptions":                     {GVR: schema.GroupVersionResource{Group: "operators.coreos.com", Version: "v1alpha1", Resource: "subscriptions"}, Namespaced: true},
-	"packagemanifests":                  {GVR: schema.GroupVersionResource{Group: "packages.operators.coreos.com", Version: "v1", Resource: "packagemanifests"}, Namespaced: true},
-	"poddisruptionbudgets":              {GVR: schema.GroupVersionResource{Group: "policy", Version: "v1", Resource: "poddisruptionbudgets"}, Namespaced: true},
-	"clusterrolebindings":               {GVR: schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterrolebindings"}, Namespaced: false},
-	"clusterroles":                      {GVR: schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterroles"}, Namespaced: false},
-	"rolebindings":                      {GVR: schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "rolebindings"}, Namespaced: true},
-	"roles":                             {GVR: schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "roles"}, Namespaced: true},
-	"priorityclasses":                   {GVR: schema.GroupVersionResource{Group: "scheduling.k8s.io", Version: "v1", Resource: "priorityclasses"}, Namespaced: false},
-	"csidrivers":                        {GVR: schema.GroupVersionResource{Group: "storage.k8s.io", Version: "v1", Resource: "csidrivers"}, Namespaced: false},
-	"csinodes":                          {GVR: schema.GroupVersionResource{Group: "storage.k8s.io", Version: "v1", Resource: "csinodes"}, Namespaced: false},
-	"csistoragecapacities":              {GVR: schema.GroupVersionResource{Group: "storage.k8s.io", Version: "v1", Resource: "csistoragecapacities"}, Namespaced: true},
-	"storageclasses":                    {GVR: schema.GroupVersionResource{Group: "storage.k8s.io", Version: "v1", Resource: "storageclasses"}, Namespaced: false},
-	"volumeattachments":                 {GVR: schema.GroupVersionResource{Group: "storage.k8s.io", Version: "v1", Resource: "volumeattachments"}, Namespaced: false},
-}
+// Confirm asks for confirmation before changes are made.
+var Confirm = true
 
-func CreateResource(ctx context.Context, client *utils.OpenAI, input, kubeConfig string) (string, error) {
-	sysPrompt := `
+// CreatePrompt instructs the model generating manifests.
+var CreatePrompt = `
 You're a K8s resource YAML manifest generator.
 Please generate corresponding YAML manifest based on user input.
+If several resources are required, separate their manifests by "---".
 Please DON'T include it into YAML code block.
 `
 
+// UpdatePrompt instructs the model generating patches.
+var UpdatePrompt = `
+You're a K8s resource patch generator.
+Please generate a patch in JSON which applies the delta to the given YAML manifest.
+The patch shall only contain the fields to change, under spec, data or metadata labels & annotations.
+To remove a field, e.g. a label or an annotation, set its value to null.
+NEVER include status, apiVersion, kind, metadata name, namespace or resourceVersion.
+Please DON'T include it into JSON code block.
+`
+
+func CreateResource(ctx context.Context, client utils.LLM, input string, clientGo *utils.ClientGo) (string, error) {
 	// generate YAML manifest given user input
-	yml, err := client.SendMessage(sysPrompt, input)
+	yml, err := client.SendMessage(ctx, CreatePrompt, input)
 	if err != nil {
 		return "", err
 	}
 	//return yamlContent, nil
 
-	// yaml to unstructured
-	unstructuredObj := &unstructured.Unstructured{}
-	_, _, err = scheme.Codecs.UniversalDeserializer().Decode([]byte(yml), nil, unstructuredObj)
+	// create every object of manifests in dependency order
+	return handleManifests(yml, clientGo, func(ri dynamic.ResourceInterface, obj *unstructured.Unstructured) (string, error) {
+		return createWithPreview(ctx, ri, obj)
+	})
+}
+
+func ListResource(ctx context.Context, namespace, resource, labelSelector, fieldSelector, output string, allNamespaces bool, clientGo *utils.ClientGo) (string, error) {
+	output, err := outputFormat(output, "table")
 	if err != nil {
 		return "", err
 	}
-
-	// client-go
-	clientGo, err := utils.NewClientGo(kubeConfig)
+	res, err := clientGo.ResolveResource(resource)
 	if err != nil {
 		return "", err
 	}
+	// namespace is ignored across all namespaces as kubectl get -A does, cluster-scoped resources are not affected
+	allNamespaces = allNamespaces && res.Scope.Name() == meta.RESTScopeNameNamespace
+	if allNamespaces {
+		namespace = metav1.NamespaceAll
+	} else {
+		namespace, err = clientGo.NamespaceFor(res, namespace)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// selectors are applied server-side
+	opts := metav1.ListOptions{LabelSelector: labelSelector, FieldSelector: fieldSelector}
 
-	// kubectl api-resources → REST mapper
-	res, err := restmapper.GetAPIGroupResources(clientGo.DiscoveryClient)
+	var result string
+	switch output {
+	case "table", "wide":
+		// list as Table to get the same columns as kubectl get
+		table, err := clientGo.ListTable(ctx, res, namespace, opts)
+		if err != nil {
+			return "", err
+		}
+		if len(table.Rows) == 0 {
+			return noResources(namespace), nil
+		}
+		result = printTable(table, output == "wide", allNamespaces)
+	default:
+		resList, err := clientGo.DynamicClient.Resource(res.Resource).Namespace(namespace).List(ctx, opts)
+		if err != nil {
+			return "", err
+		}
+		if len(resList.Items) == 0 {
+			return noResources(namespace), nil
+		}
+		result, err = printObjects(res, resList.Items, output)
+		if err != nil {
+			return "", err
+		}
+	}
+	// the result is shown to the user as well
+	fmt.Print(result)
+
+	return result, nil
+}
+
+func GetResource(ctx context.Context, namespace, resource, resourceName, output string, clientGo *utils.ClientGo) (string, error) {
+	output, err := outputFormat(output, "yaml")
 	if err != nil {
 		return "", err
 	}
-	mapper := restmapper.NewDiscoveryRESTMapper(res)
-
-	// get gvr from gvk of unstructured
-	gvk := unstructuredObj.GroupVersionKind()
-	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	res, err := clientGo.ResolveResource(resource)
 	if err != nil {
 		return "", err
 	}
 
-	namespace := unstructuredObj.GetNamespace()
+	var result string
+	switch output {
+	case "table", "wide":
+		table, err := clientGo.GetTable(ctx, res, namespace, resourceName)
+		if err != nil {
+			return "", err
+		}
+		result = printTable(table, output == "wide", false)
+	default:
+		ri, err := clientGo.ResourceInterface(res, namespace)
+		if err != nil {
+			return "", err
+		}
+		unStruct, err := ri.Get(ctx, resourceName, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		result, err = printObject(res, unStruct, output)
+		if err != nil {
+			return "", err
+		}
+	}
+	fmt.Print(result)
+
+	return result, nil
+}
+
+func noResources(namespace string) string {
 	if namespace == "" {
-		namespace = "default"
+		return "No resources found"
 	}
+	return fmt.Sprintf("No resources found in namespace [%s]", namespace)
+}
 
-	// create unstructured gvr
-	_, err = clientGo.DynamicClient.Resource(mapping.Resource).Namespace(namespace).Create(ctx, unstructuredObj, metav1.CreateOptions{})
+func UpdateResource(ctx context.Context, client utils.LLM, namespace, resource, resourceName, delta string, clientGo *utils.ClientGo) (string, error) {
+	res, err := clientGo.ResolveResource(resource)
+	if err != nil {
+		return "", err
+	}
+	ri, err := clientGo.ResourceInterface(res, namespace)
 	if err != nil {
 		return "", err
 	}
-	return fmt.Sprintf("Resource [%s] created successfully", unstructuredObj.GetName()), nil
-
-}
 
-func ListResource(ctx context.Context, namespace, resource, kubeConfig string) (string, error) {
-	clientGo, err := utils.NewClientGo(kubeConfig)
+	// get current res
+	unStruct, err := ri.Get(ctx, resourceName, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	current := unStruct.DeepCopy()
+	unstructured.RemoveNestedField(current.Object, "status")
+	yml, err := toYAML(current)
 	if err != nil {
 		return "", err
 	}
 
-	var resList *unstructured.UnstructuredList
-	if res, ok := resourceMap[resource]; !ok {
-		return "", fmt.Errorf("resource [%s] not supported", resource)
-	} else {
-		if res.Namespaced {
-			resList, err = clientGo.DynamicClient.Resource(res.GVR).Namespace(namespace).List(ctx, metav1.ListOptions{})
-			if err != nil {
-				return "", err
-			}
-		} else {
-			resList, err = clientGo.DynamicClient.Resource(res.GVR).List(ctx, metav1.ListOptions{})
-			if err != nil {
-				return "", err
-			}
-		}
+	// generate patch given delta, built-in kinds take strategic merge patch which merges lists of named items
+	patchType := types.MergePatchType
+	patchHint := "Generate a JSON merge patch (RFC 7386), lists are replaced as a whole."
+	if scheme.Scheme.Recognizes(unStruct.GroupVersionKind()) {
+		patchType = types.StrategicMergePatchType
+		patchHint = "Generate a strategic merge patch, lists of named items like containers, env, ports & volumes are merged by name, so only include the items to change with their names."
 	}
-	result := ""
-	for _, item := range resList.Items {
-		result += fmt.Sprintf("%s\n", item.GetName())
+	patch, err := client.SendMessage(ctx, UpdatePrompt, string(yml)+"\n"+patchHint+"\nThe delta is: "+delta)
+	if err != nil {
+		return "", err
+	}
+	patch = trimCodeBlock(patch)
+	if err := validatePatch([]byte(patch)); err != nil {
+		return "", err
 	}
 
-	return result, nil
+	return patchWithPreview(ctx, ri, unStruct, patchType, []byte(patch))
 }
 
-func UpdateResource(ctx context.Context, client *utils.OpenAI, namespace, resource, resourceName, delta, kubeConfig string) (string, error) {
-	sysPrompt := `
-You're a K8s resource YAML manifest updater.
-Please merge the given YAML manifest with delta.
-You only need to focus the spec & metadata field. 
-Especially when you're dealing with labels & annotations, if you're required to remove them,
-remove the key/value pair entirely, not just leave the key over there.
-Besides, for metadata, you should merge the delta rather than creating a new one.
-Get rid of status field.
-Please DON'T include it into YAML code block.
-`
-	clientGo, err := utils.NewClientGo(kubeConfig)
+func DeleteResource(ctx context.Context, namespace, resource, resourceName string, clientGo *utils.ClientGo) (string, error) {
+	res, err := clientGo.ResolveResource(resource)
 	if err != nil {
 		return "", err
 	}
-
-	// get current res
-	var unStruct *unstructured.Unstructured
-	if res, ok := resourceMap[resource]; !ok {
-		return "", fmt.Errorf("resource [%s] not supported", resource)
-	} else {
-		if res.Namespaced {
-			unStruct, err = clientGo.DynamicClient.Resource(res.GVR).Namespace(namespace).Get(ctx, resourceName, metav1.GetOptions{})
-			if err != nil {
-				return "", err
-			}
-			yml, err := yaml.Marshal(unStruct.Object)
-			if err != nil {
-				return "", err
-			}
-			ymlNew, err := client.SendMessage(sysPrompt, string(yml)+"\nThe delta is: "+delta)
-			if err != nil {
-				return "", err
-			}
-			unStructNew := &unstructured.Unstructured{}
-			_, _, err = scheme.Codecs.UniversalDeserializer().Decode([]byte(ymlNew), nil, unStructNew)
-			if err != nil {
-				return "", err
-			}
-			_, err = clientGo.DynamicClient.Resource(res.GVR).Namespace(namespace).Update(ctx, unStructNew, metav1.UpdateOptions{})
-			if err != nil {
-				return "", err
-			}
-			return fmt.Sprintf("Resource [%s] updated successfully", resourceName), nil
-		} else {
-			unStruct, err = clientGo.DynamicClient.Resource(res.GVR).Get(ctx, resourceName, metav1.GetOptions{})
-			if err != nil {
-				return "", err
-			}
-			yml, err := yaml.Marshal(unStruct)
-			if err != nil {
-				return "", err
-			}
-			ymlNew, err := client.SendMessage(sysPrompt, string(yml)+"\nThe delta is: "+delta)
-			if err != nil {
-				return "", err
-			}
-			unStructNew := &unstructured.Unstructured{}
-			_, _, err = scheme.Codecs.UniversalDeserializer().Decode([]byte(ymlNew), nil, unStructNew)
-			if err != nil {
-				return "", err
-			}
-			_, err = clientGo.DynamicClient.Resource(res.GVR).Namespace(namespace).Update(ctx, unStructNew, metav1.UpdateOptions{})
-			if err != nil {
-				return "", err
-			}
-			return fmt.Sprintf("Resource [%s] updated successfully", resourceName), nil
-		}
+	namespace, err = clientGo.NamespaceFor(res, namespace)
+	if err != nil {
+		return "", err
 	}
-}
-
-func DeleteResource(ctx context.Context, namespace, resource, resourceName, kubeConfig string) (string, error) {
-	clientGo, err := utils.NewClientGo(kubeConfig)
+	ri, err := clientGo.ResourceInterface(res, namespace)
 	if err != nil {
 		return "", err
 	}
 
-	if res, ok := resourceMap[resource]; !ok {
-		return "", fmt.Errorf("resource [%s] not supported", resource)
-	} else {
-		fmt.Printf("Are you sure that you want to delete the resource [%s] in namespace [%s]? (yes/no): ", resourceName, namespace)
-		var confirm string
-		_, err := fmt.Scanln(&confirm)
+	if DryRun {
+		err := ri.Delete(ctx, resourceName, metav1.DeleteOptions{DryRun: dryRunAll})
 		if err != nil {
 			return "", err
 		}
+		return fmt.Sprintf("Dry run: resource [%s] would be deleted, nothing is applied", resourceName), nil
+	}
 
-		if confirm != "yes" {
-			return "Deletion aborted by user.", nil
-		}
+	question := fmt.Sprintf("Are you sure that you want to delete the resource [%s]?", resourceName)
+	if namespace != "" {
+		question = fmt.Sprintf("Are you sure that you want to delete the resource [%s] in namespace [%s]?", resourceName, namespace)
+	}
+	ok, err := confirm(question)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "Deletion aborted by user.", nil
+	}
 
-		if res.Namespaced {
-			err := clientGo.DynamicClient.Resource(res.GVR).Namespace(namespace).Delete(ctx, resourceName, metav1.DeleteOptions{})
-			if err != nil {
-				return "", err
-			}
-		} else {
-			err := clientGo.DynamicClient.Resource(res.GVR).Delete(ctx, resourceName, metav1.DeleteOptions{})
-			if err != nil {
-				return "", err
-			}
-		}
+	err = ri.Delete(ctx, resourceName, metav1.DeleteOptions{})
+	if err != nil {
+		return "", err
 	}
 
 	return fmt.Sprintf("Resource [%s] deleted successfully", resourceName), nil
@@ -0,0 +1,219 @@
+package funcs
+
+import (
+	"context"
+	"fmt"
+	"github.com/KokoiRuby/k8s-copilot/cmd/utils"
+	"io"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultTailLines limits the logs if neither tail lines nor since is given.
+	defaultTailLines = 100
+	// defaultLimitBytes caps the logs handed to the model.
+	defaultLimitBytes = 16 * 1024
+	// minPodLimitBytes keeps a few lines of each pod read if the cap is shared by many pods.
+	minPodLimitBytes = 1024
+	// defaultContainerAnnotation picks the container of multi-container pods, as kubectl logs does.
+	defaultContainerAnnotation = "kubectl.kubernetes.io/default-container"
+)
+
+// GetLogs reads logs of the pod, or of the pods selected by a workload like Deployment or StatefulSet.
+// Only the last limitBytes are kept, shared by the pods, a pod failing to read is reported in its own section.
+func GetLogs(ctx context.Context, namespace, resource, resourceName, container string, previous bool, tailLines int64, since string, limitBytes int64, clientGo *utils.ClientGo) (string, error) {
+	if resource == "" {
+		resource = "pods"
+	}
+	res, err := clientGo.ResolveResource(resource)
+	if err != nil {
+		return "", err
+	}
+	namespace, err = clientGo.NamespaceFor(res, namespace)
+	if err != nil {
+		return "", err
+	}
+
+	opts := &corev1.PodLogOptions{Container: container, Previous: previous}
+	if since != "" {
+		d, err := time.ParseDuration(since)
+		if err != nil {
+			return "", fmt.Errorf("since [%s] is not a duration like 5m or 1h: %w", since, err)
+		}
+		seconds := int64(d.Seconds())
+		opts.SinceSeconds = &seconds
+	}
+	if tailLines <= 0 && since == "" {
+		tailLines = defaultTailLines
+	}
+	if tailLines > 0 {
+		opts.TailLines = &tailLines
+	}
+	if limitBytes <= 0 {
+		limitBytes = defaultLimitBytes
+	}
+
+	pods, err := workloadPods(ctx, res, namespace, resourceName, clientGo)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	read, podLimitBytes, skipped := splitLimitBytes(len(pods), limitBytes)
+	b.WriteString(skipped)
+	pods = pods[:read]
+
+	for _, pod := range pods {
+		podOpts := *opts
+		if podOpts.Container == "" {
+			podOpts.Container = defaultContainer(pod)
+		}
+		header := ""
+		if len(pods) > 1 {
+			header = fmt.Sprintf("==> pod [%s] container [%s] <==\n", pod.Name, podOpts.Container)
+		}
+		logs, err := podLogs(ctx, pod, &podOpts, max(podLimitBytes-int64(len(header)), 1), clientGo)
+		if err != nil {
+			if len(pods) == 1 {
+				return "", err
+			}
+			// a failing pod doesn't hide logs of the others
+			logs = fmt.Sprintf("error: %v\n", err)
+		}
+		b.WriteString(header)
+		b.WriteString(logs)
+	}
+	result := b.String()
+	if result == "" {
+		result = "No logs found"
+	}
+	fmt.Println(result)
+	return result, nil
+}
+
+// splitLimitBytes shares the cap by the pods, pods beyond it are skipped to keep a few lines of each pod read.
+// It returns the number of pods to read, the cap of each pod & the note of skipped pods, which counts in the cap.
+func splitLimitBytes(pods int, limitBytes int64) (int, int64, string) {
+	skipped := ""
+	if maxPods := int(max(limitBytes/minPodLimitBytes, 1)); pods > maxPods {
+		skipped = fmt.Sprintf("... (logs of %d more pods skipped, get logs of a pod by its name)\n", pods-maxPods)
+		pods = maxPods
+	}
+	return pods, max((limitBytes-int64(len(skipped)))/int64(pods), 1), skipped
+}
+
+// workloadPods finds the pod by name, or the pods selected by the workload.
+func workloadPods(ctx context.Context, res *meta.RESTMapping, namespace, name string, clientGo *utils.ClientGo) ([]corev1.Pod, error) {
+	kind := res.GroupVersionKind.Kind
+	if kind == "Pod" {
+		pod, err := clientGo.ClientSet.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return []corev1.Pod{*pod}, nil
+	}
+
+	selector, err := workloadSelector(ctx, res, namespace, name, clientGo)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := clientGo.ClientSet.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+	if len(pods.Items) == 0 {
+		return nil, fmt.Errorf("no pods found for %s [%s] in namespace [%s]", kind, name, namespace)
+	}
+	return pods.Items, nil
+}
+
+// workloadSelector gets the label selector of pods managed by the workload.
+func workloadSelector(ctx context.Context, res *meta.RESTMapping, namespace, name string, clientGo *utils.ClientGo) (string, error) {
+	obj, err := clientGo.DynamicClient.Resource(res.Resource).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	return podSelector(obj)
+}
+
+// podSelector converts spec.selector of the workload to a label selector.
+func podSelector(obj *unstructured.Unstructured) (string, error) {
+	raw, ok, _ := unstructured.NestedMap(obj.Object, "spec", "selector")
+	if !ok {
+		return "", fmt.Errorf("%s [%s] has no pod selector", obj.GetKind(), obj.GetName())
+	}
+	labelSelector := &metav1.LabelSelector{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, labelSelector); err != nil {
+		return "", err
+	}
+	selector, err := metav1.LabelSelectorAsSelector(labelSelector)
+	if err != nil {
+		return "", err
+	}
+	if selector.Empty() {
+		return "", fmt.Errorf("%s [%s] has an empty pod selector", obj.GetKind(), obj.GetName())
+	}
+	return selector.String(), nil
+}
+
+// defaultContainer picks the container by annotation, or the first one.
+func defaultContainer(pod corev1.Pod) string {
+	if name := pod.Annotations[defaultContainerAnnotation]; name != "" {
+		return name
+	}
+	if len(pod.Spec.Containers) > 0 {
+		return pod.Spec.Containers[0].Name
+	}
+	return ""
+}
+
+// podLogs streams logs of the pod & keeps the last limitBytes of them, including the truncation note.
+func podLogs(ctx context.Context, pod corev1.Pod, opts *corev1.PodLogOptions, limitBytes int64, clientGo *utils.ClientGo) (string, error) {
+	stream, err := clientGo.ClientSet.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, opts).Stream(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer stream.Close()
+
+	logs, truncated, err := tailBytes(stream, int(limitBytes))
+	if err != nil {
+		return "", err
+	}
+	if truncated {
+		note := fmt.Sprintf("... (truncated to the last %d bytes)\n", limitBytes)
+		logs = logs[min(len(note), len(logs)):]
+		// drop the partial first line
+		if i := strings.IndexByte(logs, '\n'); i >= 0 {
+			logs = logs[i+1:]
+		}
+		return note + logs, nil
+	}
+	return logs, nil
+}
+
+// tailBytes reads r to the end, only the last limit bytes are kept in memory.
+func tailBytes(r io.Reader, limit int) (string, bool, error) {
+	var buf []byte
+	chunk := make([]byte, 32*1024)
+	truncated := false
+	for {
+		n, err := r.Read(chunk)
+		buf = append(buf, chunk[:n]...)
+		if len(buf) > limit {
+			buf = append(buf[:0], buf[len(buf)-limit:]...)
+			truncated = true
+		}
+		if err == io.EOF {
+			return string(buf), truncated, nil
+		}
+		if err != nil {
+			return "", false, err
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package funcs
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestSplitLimitBytes(t *testing.T) {
+	tests := []struct {
+		name         string
+		pods         int
+		limitBytes   int64
+		wantRead     int
+		wantPodLimit int64
+		wantSkipped  string
+	}{
+		{
+			name:         "single pod takes the whole cap",
+			pods:         1,
+			limitBytes:   16384,
+			wantRead:     1,
+			wantPodLimit: 16384,
+		},
+		{
+			name:         "pods share the cap",
+			pods:         4,
+			limitBytes:   16384,
+			wantRead:     4,
+			wantPodLimit: 4096,
+		},
+		{
+			name:         "as many pods as the cap keeps",
+			pods:         16,
+			limitBytes:   16384,
+			wantRead:     16,
+			wantPodLimit: 1024,
+		},
+		{
+			name:         "pods beyond the cap are skipped",
+			pods:         20,
+			limitBytes:   16384,
+			wantRead:     16,
+			wantPodLimit: (16384 - 65) / 16,
+			wantSkipped:  "... (logs of 4 more pods skipped, get logs of a pod by its name)\n",
+		},
+		{
+			name:         "one pod is read below the minimum",
+			pods:         3,
+			limitBytes:   512,
+			wantRead:     1,
+			wantPodLimit: 512 - 65,
+			wantSkipped:  "... (logs of 2 more pods skipped, get logs of a pod by its name)\n",
+		},
+		{
+			name:         "the cap is never below one byte",
+			pods:         2,
+			limitBytes:   10,
+			wantRead:     1,
+			wantPodLimit: 1,
+			wantSkipped:  "... (logs of 1 more pods skipped, get logs of a pod by its name)\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			read, podLimit, skipped := splitLimitBytes(tt.pods, tt.limitBytes)
+			if read != tt.wantRead || podLimit != tt.wantPodLimit || skipped != tt.wantSkipped {
+				t.Errorf("splitLimitBytes(%d, %d) = %d, %d, %q, want %d, %d, %q", tt.pods, tt.limitBytes,
+					read, podLimit, skipped, tt.wantRead, tt.wantPodLimit, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+func TestTailBytes(t *testing.T) {
+	logs := strings.Repeat("0123456789", 10000)
+	tests := []struct {
+		name          string
+		logs          string
+		limit         int
+		oneByte       bool
+		want          string
+		wantTruncated bool
+	}{
+		{"empty", "", 10, false, "", false},
+		{"within the limit", "line 1\nline 2\n", 100, false, "line 1\nline 2\n", false},
+		{"exactly the limit", "0123456789", 10, false, "0123456789", false},
+		{"last bytes are kept", "line 1\nline 2\n", 7, false, "line 2\n", true},
+		{"beyond a chunk", logs, 15, false, "567890123456789", true},
+		{"short reads", "line 1\nline 2\n", 7, true, "line 2\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r io.Reader = strings.NewReader(tt.logs)
+			if tt.oneByte {
+				r = iotest.OneByteReader(r)
+			}
+			got, truncated, err := tailBytes(r, tt.limit)
+			if err != nil {
+				t.Fatalf("tailBytes() error = %v", err)
+			}
+			if got != tt.want || truncated != tt.wantTruncated {
+				t.Errorf("tailBytes() = %q, %v, want %q, %v", got, truncated, tt.want, tt.wantTruncated)
+			}
+		})
+	}
+
+	if _, _, err := tailBytes(iotest.ErrReader(iotest.ErrTimeout), 10); err != iotest.ErrTimeout {
+		t.Errorf("tailBytes() error = %v, want %v", err, iotest.ErrTimeout)
+	}
+}
@@ -0,0 +1,161 @@
+package funcs
+
+import (
+	"errors"
+	"fmt"
+	"github.com/KokoiRuby/k8s-copilot/cmd/utils"
+	"io"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/dynamic"
+	"sort"
+	"strings"
+)
+
+// kindOrder is the order to create objects of kinds, so that dependencies go first:
+// namespaces, CRDs, RBAC & config, then workloads. Other kinds go last.
+var kindOrder = []string{
+	"Namespace",
+	"NetworkPolicy",
+	"ResourceQuota",
+	"LimitRange",
+	"PodDisruptionBudget",
+	"ServiceAccount",
+	"Secret",
+	"ConfigMap",
+	"StorageClass",
+	"PersistentVolume",
+	"PersistentVolumeClaim",
+	"CustomResourceDefinition",
+	"ClusterRole",
+	"ClusterRoleBinding",
+	"Role",
+	"RoleBinding",
+	"Service",
+	"DaemonSet",
+	"Pod",
+	"ReplicationController",
+	"ReplicaSet",
+	"Deployment",
+	"HorizontalPodAutoscaler",
+	"StatefulSet",
+	"Job",
+	"CronJob",
+	"IngressClass",
+	"Ingress",
+	"APIService",
+}
+
+// decodeManifests decodes every document of YAML manifests & sorts the objects in dependency order,
+// items of a List are flattened.
+func decodeManifests(yml string) ([]*unstructured.Unstructured, error) {
+	var objs []*unstructured.Unstructured
+	decoder := utilyaml.NewYAMLOrJSONDecoder(strings.NewReader(trimCodeBlock(yml)), 4096)
+	for {
+		raw := runtime.RawExtension{}
+		err := decoder.Decode(&raw)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		// empty document
+		if len(raw.Raw) == 0 || string(raw.Raw) == "null" {
+			continue
+		}
+
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(raw.Raw); err != nil {
+			return nil, err
+		}
+
+		if obj.IsList() {
+			err := obj.EachListItem(func(item runtime.Object) error {
+				objs = append(objs, item.(*unstructured.Unstructured))
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		objs = append(objs, obj)
+	}
+	if len(objs) == 0 {
+		return nil, errors.New("no object found in the manifests")
+	}
+
+	sort.SliceStable(objs, func(i, j int) bool {
+		return kindRank(objs[i].GetKind()) < kindRank(objs[j].GetKind())
+	})
+	return objs, nil
+}
+
+func kindRank(kind string) int {
+	for i, k := range kindOrder {
+		if k == kind {
+			return i
+		}
+	}
+	return len(kindOrder)
+}
+
+// handleManifests decodes the manifests & handles each object in dependency order,
+// it keeps going on failures & reports the result of every object.
+func handleManifests(yml string, clientGo *utils.ClientGo,
+	handle func(ri dynamic.ResourceInterface, obj *unstructured.Unstructured) (string, error)) (string, error) {
+	objs, err := decodeManifests(yml)
+	if err != nil {
+		return "", err
+	}
+
+	var results []string
+	// namespaces created by dry-run in this batch, objects in them can't be validated by server-side dry-run
+	dryRunNamespaces := map[string]bool{}
+	for _, obj := range objs {
+		result, err := handleObject(obj, clientGo, handle)
+		if ns := obj.GetNamespace(); err != nil && dryRunNamespaces[ns] && isNamespaceNotFound(err, ns) {
+			result = fmt.Sprintf("Dry run: resource [%s] is not validated, namespace [%s] would be created by the same manifests", obj.GetName(), ns)
+			err = nil
+		}
+		if err == nil && DryRun && obj.GetKind() == "Namespace" && obj.GroupVersionKind().Group == "" {
+			dryRunNamespaces[obj.GetName()] = true
+		}
+		if err != nil {
+			result = fmt.Sprintf("Error: %s", err.Error())
+		}
+		if len(objs) == 1 {
+			return result, err
+		}
+		results = append(results, fmt.Sprintf("%s/%s: %s", obj.GetKind(), obj.GetName(), result))
+	}
+	return strings.Join(results, "\n"), nil
+}
+
+// isNamespaceNotFound tells if err is caused by the missing namespace.
+func isNamespaceNotFound(err error, namespace string) bool {
+	var statusErr apierrors.APIStatus
+	if !errors.As(err, &statusErr) || !apierrors.IsNotFound(err) {
+		return false
+	}
+	details := statusErr.Status().Details
+	return details != nil && details.Kind == "namespaces" && details.Name == namespace
+}
+
+func handleObject(obj *unstructured.Unstructured, clientGo *utils.ClientGo,
+	handle func(ri dynamic.ResourceInterface, obj *unstructured.Unstructured) (string, error)) (string, error) {
+	// get gvr from gvk of unstructured
+	mapping, err := clientGo.KindMapping(obj.GroupVersionKind())
+	if err != nil {
+		return "", err
+	}
+
+	ri, err := clientGo.ResourceInterface(mapping, obj.GetNamespace())
+	if err != nil {
+		return "", err
+	}
+	return handle(ri, obj)
+}
@@ -0,0 +1,134 @@
+package funcs
+
+import (
+	"context"
+	"github.com/KokoiRuby/k8s-copilot/cmd/utils"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"strings"
+	"testing"
+)
+
+func TestDecodeManifests(t *testing.T) {
+	manifests := "```yaml\n" + `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: first
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+---
+---
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: config
+- apiVersion: example.com/v1
+  kind: Widget
+  metadata:
+    name: second
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: demo
+` + "```"
+	objs, err := decodeManifests(manifests)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, obj := range objs {
+		got = append(got, obj.GetKind()+"/"+obj.GetName())
+	}
+	// dependencies first, unknown kinds last in their original order
+	want := "Namespace/demo ConfigMap/config Service/web Deployment/web Widget/first Widget/second"
+	if strings.Join(got, " ") != want {
+		t.Errorf("objects = %s, want %s", strings.Join(got, " "), want)
+	}
+}
+
+func TestDecodeManifestsEmpty(t *testing.T) {
+	if _, err := decodeManifests("---\n---\n"); err == nil {
+		t.Error("expect an error for manifests without objects")
+	}
+}
+
+// newTestClientGo maps a few kinds & fails creating objects in namespaces other than default, as if they don't exist.
+func newTestClientGo() *utils.ClientGo {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	dynamicClient.PrependReactor("create", "*", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if ns := action.GetNamespace(); ns != "" && ns != "default" {
+			return true, nil, apierrors.NewNotFound(schema.GroupResource{Resource: "namespaces"}, ns)
+		}
+		return false, nil, nil
+	})
+	return &utils.ClientGo{DynamicClient: dynamicClient, Mapper: mapper, Namespace: "default"}
+}
+
+func TestHandleManifestsDryRunNamespace(t *testing.T) {
+	origin := DryRun
+	t.Cleanup(func() { DryRun = origin })
+	DryRun = true
+
+	manifests := `apiVersion: v1
+kind: Namespace
+metadata:
+  name: demo
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: demo
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  namespace: missing
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+`
+	got, err := handleManifests(manifests, newTestClientGo(), func(ri dynamic.ResourceInterface, obj *unstructured.Unstructured) (string, error) {
+		return createWithPreview(context.Background(), ri, obj)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"Namespace/demo: Dry run: resource [demo] would be created, nothing is applied",
+		// the namespace of the same manifests doesn't exist in dry-run
+		"ConfigMap/config: Error: namespaces \"missing\" not found",
+		"ConfigMap/config: Dry run: resource [config] would be created, nothing is applied",
+		"Deployment/web: Dry run: resource [web] is not validated, namespace [demo] would be created by the same manifests",
+	}
+	if got != strings.Join(want, "\n") {
+		t.Errorf("results =\n%s\nwant\n%s", got, strings.Join(want, "\n"))
+	}
+}
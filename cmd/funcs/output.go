@@ -0,0 +1,85 @@
+package funcs
+
+import (
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"strings"
+)
+
+// Output is the default output format of resources, overridden by the output argument of functions.
+// If empty, lists are shown as table & single objects as yaml.
+var Output string
+
+// Outputs are the supported output formats, as kubectl get -o does.
+var Outputs = []string{"table", "wide", "yaml", "json", "name"}
+
+// outputFormat validates the output format, Output or else fallback is used if output is empty.
+func outputFormat(output, fallback string) (string, error) {
+	if output == "" {
+		output = Output
+	}
+	if output == "" {
+		return fallback, nil
+	}
+	output = strings.ToLower(strings.TrimSpace(output))
+	for _, o := range Outputs {
+		if o == output {
+			return output, nil
+		}
+	}
+	return "", fmt.Errorf("output [%s] not supported, supported: %s", output, strings.Join(Outputs, ", "))
+}
+
+// printObjects renders the objects as a List in yaml or compact json, or as names like kubectl -o name.
+func printObjects(mapping *meta.RESTMapping, objs []unstructured.Unstructured, output string) (string, error) {
+	if output == "name" {
+		kind := strings.ToLower(mapping.GroupVersionKind.Kind)
+		if group := mapping.GroupVersionKind.Group; group != "" {
+			kind += "." + group
+		}
+		var b strings.Builder
+		for _, obj := range objs {
+			fmt.Fprintf(&b, "%s/%s\n", kind, obj.GetName())
+		}
+		return b.String(), nil
+	}
+
+	items := make([]interface{}, 0, len(objs))
+	for _, obj := range objs {
+		items = append(items, cleanObject(&obj).Object)
+	}
+	return marshal(map[string]interface{}{"apiVersion": "v1", "kind": "List", "items": items}, output)
+}
+
+// printObject renders a single object in yaml, compact json or as name.
+func printObject(mapping *meta.RESTMapping, obj *unstructured.Unstructured, output string) (string, error) {
+	if output == "name" {
+		return printObjects(mapping, []unstructured.Unstructured{*obj}, output)
+	}
+	return marshal(cleanObject(obj).Object, output)
+}
+
+// cleanObject copies the object without noisy fields like managedFields.
+func cleanObject(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	obj = obj.DeepCopy()
+	unstructured.RemoveNestedField(obj.Object, "metadata", "managedFields")
+	return obj
+}
+
+func marshal(v interface{}, output string) (string, error) {
+	if output == "json" {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(data) + "\n", nil
+	}
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
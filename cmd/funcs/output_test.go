@@ -0,0 +1,111 @@
+package funcs
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"strings"
+	"testing"
+)
+
+func TestOutputFormat(t *testing.T) {
+	tests := []struct {
+		output   string
+		fallback string
+		defaults string
+		want     string
+		wantErr  bool
+	}{
+		{"", "table", "", "table", false},
+		{"", "yaml", "", "yaml", false},
+		{"", "table", "json", "json", false},
+		{"wide", "table", "json", "wide", false},
+		{" YAML ", "table", "", "yaml", false},
+		{"name", "table", "", "name", false},
+		{"jsonpath", "table", "", "", true},
+	}
+	defer func(output string) { Output = output }(Output)
+	for _, tt := range tests {
+		Output = tt.defaults
+		got, err := outputFormat(tt.output, tt.fallback)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("outputFormat(%q, %q) error = %v, wantErr %v", tt.output, tt.fallback, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("outputFormat(%q, %q) with Output %q = %q, want %q", tt.output, tt.fallback, tt.defaults, got, tt.want)
+		}
+	}
+}
+
+func TestPrintObjects(t *testing.T) {
+	deploy := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":          "web",
+			"managedFields": []interface{}{map[string]interface{}{"manager": "kubectl"}},
+		},
+	}}
+	objs := []unstructured.Unstructured{deploy}
+	deployments := &meta.RESTMapping{GroupVersionKind: schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}}
+	pods := &meta.RESTMapping{GroupVersionKind: schema.GroupVersionKind{Version: "v1", Kind: "Pod"}}
+
+	tests := []struct {
+		name    string
+		mapping *meta.RESTMapping
+		output  string
+		want    string
+	}{
+		{
+			name:    "name with group",
+			mapping: deployments,
+			output:  "name",
+			want:    "deployment.apps/web\n",
+		},
+		{
+			name:    "name of core group",
+			mapping: pods,
+			output:  "name",
+			want:    "pod/web\n",
+		},
+		{
+			name:    "json",
+			mapping: deployments,
+			output:  "json",
+			want:    `{"apiVersion":"v1","items":[{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"web"}}],"kind":"List"}` + "\n",
+		},
+		{
+			name:    "yaml",
+			mapping: deployments,
+			output:  "yaml",
+			want: `apiVersion: v1
+items:
+    - apiVersion: apps/v1
+      kind: Deployment
+      metadata:
+        name: web
+kind: List
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := printObjects(tt.mapping, objs, tt.output)
+			if err != nil {
+				t.Fatalf("printObjects() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("printObjects() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+
+	// managed fields are only stripped from the output
+	if _, ok := deploy.Object["metadata"].(map[string]interface{})["managedFields"]; !ok {
+		t.Error("printObjects() modified the objects")
+	}
+	if got, _ := printObjects(deployments, nil, "name"); strings.TrimSpace(got) != "" {
+		t.Errorf("printObjects() of no objects = %q, want empty", got)
+	}
+}
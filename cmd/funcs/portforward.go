@@ -0,0 +1,292 @@
+package funcs
+
+import (
+	"context"
+	"fmt"
+	"github.com/KokoiRuby/k8s-copilot/cmd/utils"
+	"io"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// forward is a port-forward session running in background.
+type forward struct {
+	id     int
+	target string
+	pod    string
+	ports  []string
+	stop   chan struct{}
+	done   chan struct{}
+	// err tells why the session ended by itself, valid once done is closed
+	err error
+}
+
+// forwards are the port-forward sessions of the copilot, they live until stopped or the copilot exits.
+var forwards = struct {
+	sync.Mutex
+	sessions map[int]*forward
+	nextID   int
+}{sessions: map[int]*forward{}, nextID: 1}
+
+// PortForward forwards local ports to a ready pod of the pod, service or workload in background, as kubectl port-forward does.
+// Ports are given as "local:remote", "remote" for the same local port or ":remote" for a random one.
+// Ports of services are translated to the target ports of the pod.
+func PortForward(ctx context.Context, namespace, resource, resourceName string, ports []string, clientGo *utils.ClientGo) (string, error) {
+	if len(ports) == 0 {
+		return "", fmt.Errorf("ports must not be empty")
+	}
+	if resource == "" {
+		resource = "pods"
+	}
+	res, err := clientGo.ResolveResource(resource)
+	if err != nil {
+		return "", err
+	}
+	namespace, err = clientGo.NamespaceFor(res, namespace)
+	if err != nil {
+		return "", err
+	}
+
+	var pods []corev1.Pod
+	var svc *corev1.Service
+	if res.GroupVersionKind.Kind == "Service" {
+		svc, err = clientGo.ClientSet.CoreV1().Services(namespace).Get(ctx, resourceName, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		if len(svc.Spec.Selector) == 0 {
+			return "", fmt.Errorf("service [%s] has no selector to find its pods", resourceName)
+		}
+		podList, err := clientGo.ClientSet.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: labels.SelectorFromSet(svc.Spec.Selector).String(),
+		})
+		if err != nil {
+			return "", err
+		}
+		pods = podList.Items
+	} else {
+		pods, err = workloadPods(ctx, res, namespace, resourceName, clientGo)
+		if err != nil {
+			return "", err
+		}
+	}
+	pod, ok := readyPod(pods)
+	if !ok {
+		return "", fmt.Errorf("no ready pod found for resource [%s]", resourceName)
+	}
+	if svc != nil {
+		if ports, err = servicePorts(svc, pod, ports); err != nil {
+			return "", err
+		}
+	}
+
+	req := clientGo.ClientSet.CoreV1().RESTClient().Post().
+		Resource("pods").Namespace(pod.Namespace).Name(pod.Name).SubResource("portforward")
+	dialer, err := newDialer(clientGo.Config, req.URL())
+	if err != nil {
+		return "", err
+	}
+
+	f := &forward{
+		target: fmt.Sprintf("%s/%s", strings.ToLower(res.GroupVersionKind.Kind), resourceName),
+		pod:    pod.Name,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	ready := make(chan struct{})
+	// logs of connections would mess up the REPL
+	pf, err := portforward.NewOnAddresses(dialer, []string{"localhost"}, ports, f.stop, ready, io.Discard, io.Discard)
+	if err != nil {
+		return "", err
+	}
+	go func() {
+		f.err = pf.ForwardPorts()
+		close(f.done)
+	}()
+	select {
+	case <-ready:
+	case <-f.done:
+		return "", fmt.Errorf("port-forward to pod [%s] failed: %w", pod.Name, f.err)
+	}
+
+	forwarded, err := pf.GetPorts()
+	if err != nil {
+		close(f.stop)
+		return "", err
+	}
+	for _, p := range forwarded {
+		f.ports = append(f.ports, fmt.Sprintf("localhost:%d -> %d", p.Local, p.Remote))
+	}
+
+	forwards.Lock()
+	f.id = forwards.nextID
+	forwards.nextID++
+	forwards.sessions[f.id] = f
+	forwards.Unlock()
+
+	return fmt.Sprintf("Port-forward [%d] started: %s via pod [%s], %s. Type \"/forwards\" to list & \"/stop %d\" to stop it.",
+		f.id, f.target, f.pod, strings.Join(f.ports, ", "), f.id), nil
+}
+
+// newDialer tunnels SPDY over websocket, falling back to SPDY for API servers not supporting it, as kubectl port-forward does.
+func newDialer(config *rest.Config, u *url.URL) (httpstream.Dialer, error) {
+	transport, upgrader, err := spdy.RoundTripperFor(config)
+	if err != nil {
+		return nil, err
+	}
+	spdyDialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, "POST", u)
+	websocketDialer, err := portforward.NewSPDYOverWebsocketDialer(u, config)
+	if err != nil {
+		return nil, err
+	}
+	return portforward.NewFallbackDialer(websocketDialer, spdyDialer, func(err error) bool {
+		return httpstream.IsUpgradeFailure(err) || httpstream.IsHTTPSProxyError(err)
+	}), nil
+}
+
+// readyPod picks a ready pod.
+func readyPod(pods []corev1.Pod) (corev1.Pod, bool) {
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil || pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		for _, c := range pod.Status.Conditions {
+			if c.Type == corev1.PodReady && c.Status == corev1.ConditionTrue {
+				return pod, true
+			}
+		}
+	}
+	return corev1.Pod{}, false
+}
+
+// servicePorts translates ports of the service to the target ports of the pod, named target ports are looked up in containers.
+func servicePorts(svc *corev1.Service, pod corev1.Pod, ports []string) ([]string, error) {
+	var translated []string
+	for _, port := range ports {
+		local, remote, found := strings.Cut(port, ":")
+		if !found {
+			remote = local
+		}
+		servicePort, err := strconv.Atoi(remote)
+		if err != nil {
+			return nil, fmt.Errorf("port [%s] is not a number", remote)
+		}
+		i := -1
+		for j, p := range svc.Spec.Ports {
+			if int(p.Port) == servicePort {
+				i = j
+			}
+		}
+		if i < 0 {
+			return nil, fmt.Errorf("service [%s] has no port %d", svc.Name, servicePort)
+		}
+		target := svc.Spec.Ports[i].TargetPort
+		containerPort := servicePort
+		switch {
+		case target.Type == intstr.Int && target.IntVal > 0:
+			containerPort = int(target.IntVal)
+		case target.Type == intstr.String && target.StrVal != "":
+			containerPort = namedPort(pod, target.StrVal)
+			if containerPort == 0 {
+				return nil, fmt.Errorf("pod [%s] has no port named [%s]", pod.Name, target.StrVal)
+			}
+		}
+		translated = append(translated, fmt.Sprintf("%s:%d", local, containerPort))
+	}
+	return translated, nil
+}
+
+func namedPort(pod corev1.Pod, name string) int {
+	for _, c := range pod.Spec.Containers {
+		for _, p := range c.Ports {
+			if p.Name == name {
+				return int(p.ContainerPort)
+			}
+		}
+	}
+	return 0
+}
+
+// ListForwards lists the port-forward sessions, including the ones ended by themselves.
+func ListForwards() string {
+	forwards.Lock()
+	defer forwards.Unlock()
+	if len(forwards.sessions) == 0 {
+		return "No port-forward is running."
+	}
+	ids := make([]int, 0, len(forwards.sessions))
+	for id := range forwards.sessions {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	var b strings.Builder
+	for _, id := range ids {
+		f := forwards.sessions[id]
+		status := "running"
+		select {
+		case <-f.done:
+			status = fmt.Sprintf("ended: %v", f.err)
+		default:
+		}
+		fmt.Fprintf(&b, "[%d] %s via pod [%s], %s (%s)\n", f.id, f.target, f.pod, strings.Join(f.ports, ", "), status)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// StopForward stops the port-forward session by id, or all of them if id is "all".
+func StopForward(id string) (string, error) {
+	if id == "all" {
+		n := StopForwards()
+		return fmt.Sprintf("Stopped %d port-forward(s).", n), nil
+	}
+	n, err := strconv.Atoi(id)
+	if err != nil {
+		return "", fmt.Errorf("port-forward id [%s] is not a number", id)
+	}
+
+	forwards.Lock()
+	f, ok := forwards.sessions[n]
+	delete(forwards.sessions, n)
+	forwards.Unlock()
+	if !ok {
+		return "", fmt.Errorf("port-forward [%s] not found", id)
+	}
+	f.close()
+	return fmt.Sprintf("Stopped port-forward [%d] %s.", f.id, f.target), nil
+}
+
+// StopForwards stops all port-forward sessions & tells how many of them are stopped.
+func StopForwards() int {
+	forwards.Lock()
+	sessions := forwards.sessions
+	forwards.sessions = map[int]*forward{}
+	forwards.Unlock()
+
+	for _, f := range sessions {
+		f.close()
+	}
+	return len(sessions)
+}
+
+// close stops the session & waits for its listeners to be closed.
+func (f *forward) close() {
+	select {
+	case <-f.done:
+	default:
+		close(f.stop)
+		<-f.done
+	}
+}
@@ -0,0 +1,57 @@
+package funcs
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"reflect"
+	"testing"
+)
+
+func TestServicePorts(t *testing.T) {
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web"},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{
+				{Name: "http", Port: 80, TargetPort: intstr.FromInt32(8080)},
+				{Name: "metrics", Port: 9090, TargetPort: intstr.FromString("metrics")},
+				{Name: "grpc", Port: 9000},
+				{Name: "admin", Port: 8443, TargetPort: intstr.FromString("admin")},
+			},
+		},
+	}
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-0"},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{Name: "web", Ports: []corev1.ContainerPort{{Name: "http", ContainerPort: 8080}}},
+				{Name: "exporter", Ports: []corev1.ContainerPort{{Name: "metrics", ContainerPort: 9100}}},
+			},
+		},
+	}
+
+	tests := []struct {
+		ports   []string
+		want    []string
+		wantErr bool
+	}{
+		{[]string{"80"}, []string{"80:8080"}, false},
+		{[]string{"8000:80"}, []string{"8000:8080"}, false},
+		{[]string{":80"}, []string{":8080"}, false},
+		{[]string{"9090"}, []string{"9090:9100"}, false},
+		{[]string{"9000", "80"}, []string{"9000:9000", "80:8080"}, false},
+		{[]string{"443"}, nil, true},
+		{[]string{"http"}, nil, true},
+		{[]string{"8443"}, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := servicePorts(svc, pod, tt.ports)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("servicePorts(%q) error = %v, want error %v", tt.ports, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("servicePorts(%q) = %q, want %q", tt.ports, got, tt.want)
+		}
+	}
+}
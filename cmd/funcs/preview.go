@@ -0,0 +1,173 @@
+package funcs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/pmezard/go-difflib/difflib"
+	"gopkg.in/yaml.v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"strings"
+)
+
+// DryRun only previews the changes by server-side dry-run, nothing is applied.
+var DryRun bool
+
+// dryRunAll is the dry-run option for every request to run it server-side without persisting.
+var dryRunAll = []string{metav1.DryRunAll}
+
+// confirm asks the user for approval, it's always approved if confirmation is turned off.
+func confirm(question string) (bool, error) {
+	if !Confirm {
+		return true, nil
+	}
+	return ask(question)
+}
+
+// ask asks the user for approval even if confirmation is turned off.
+func ask(question string) (bool, error) {
+	fmt.Printf("%s (yes/no): ", question)
+	var answer string
+	_, err := fmt.Scanln(&answer)
+	if err != nil {
+		return false, err
+	}
+	return answer == "yes", nil
+}
+
+// toYAML renders the object as YAML manifest without noisy fields like managedFields.
+func toYAML(obj *unstructured.Unstructured) (string, error) {
+	yml, err := yaml.Marshal(cleanObject(obj).Object)
+	if err != nil {
+		return "", err
+	}
+	return string(yml), nil
+}
+
+// diff renders the unified diff between the live object & the one to update.
+func diff(live, updated *unstructured.Unstructured) (string, error) {
+	liveYAML, err := toYAML(live)
+	if err != nil {
+		return "", err
+	}
+	updatedYAML, err := toYAML(updated)
+	if err != nil {
+		return "", err
+	}
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(liveYAML),
+		B:        difflib.SplitLines(updatedYAML),
+		FromFile: "live",
+		ToFile:   "updated",
+		Context:  3,
+	})
+}
+
+// createWithPreview shows the manifest validated by server-side dry-run, then creates the object after approval.
+func createWithPreview(ctx context.Context, ri dynamic.ResourceInterface, obj *unstructured.Unstructured) (string, error) {
+	_, err := ri.Create(ctx, obj, metav1.CreateOptions{FieldManager: FieldManager, DryRun: dryRunAll})
+	if err != nil {
+		return "", err
+	}
+	manifest, err := toYAML(obj)
+	if err != nil {
+		return "", err
+	}
+	fmt.Printf("Manifest of resource [%s]:\n%s", obj.GetName(), manifest)
+	if DryRun {
+		return fmt.Sprintf("Dry run: resource [%s] would be created, nothing is applied", obj.GetName()), nil
+	}
+
+	ok, err := confirm(fmt.Sprintf("Are you sure that you want to create the resource [%s]?", obj.GetName()))
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "Creation aborted by user.", nil
+	}
+	_, err = ri.Create(ctx, obj, metav1.CreateOptions{FieldManager: FieldManager})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Resource [%s] created successfully", obj.GetName()), nil
+}
+
+// patchWithPreview shows the patch & the diff between the live object & the one returned by server-side dry-run,
+// then patches the object after approval.
+func patchWithPreview(ctx context.Context, ri dynamic.ResourceInterface, live *unstructured.Unstructured, patchType types.PatchType, patch []byte) (string, error) {
+	dryRunObj, err := ri.Patch(ctx, live.GetName(), patchType, patch, metav1.PatchOptions{FieldManager: FieldManager, DryRun: dryRunAll})
+	if err != nil {
+		return "", err
+	}
+	d, err := diff(live, dryRunObj)
+	if err != nil {
+		return "", err
+	}
+	if d == "" {
+		return fmt.Sprintf("Resource [%s] is unchanged by patch %s", live.GetName(), patch), nil
+	}
+	fmt.Printf("Patch of resource [%s]:\n%s\nDiff:\n%s", live.GetName(), patch, d)
+	if DryRun {
+		return fmt.Sprintf("Dry run: resource [%s] would be patched by %s, nothing is applied", live.GetName(), patch), nil
+	}
+
+	ok, err := confirm(fmt.Sprintf("Are you sure that you want to update the resource [%s]?", live.GetName()))
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "Update aborted by user.", nil
+	}
+	_, err = ri.Patch(ctx, live.GetName(), patchType, patch, metav1.PatchOptions{FieldManager: FieldManager})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Resource [%s] updated successfully by patch %s", live.GetName(), patch), nil
+}
+
+// validatePatch makes sure the patch is a JSON object which only touches what is meant to be changed.
+func validatePatch(patch []byte) error {
+	obj := map[string]interface{}{}
+	if err := json.Unmarshal(patch, &obj); err != nil {
+		return fmt.Errorf("invalid patch, expect a JSON object: %w", err)
+	}
+	if len(obj) == 0 {
+		return fmt.Errorf("empty patch")
+	}
+	for _, field := range []string{"status", "apiVersion", "kind"} {
+		if _, found := obj[field]; found {
+			return fmt.Errorf("patch must not touch field [%s]", field)
+		}
+	}
+	metadata, found := obj["metadata"]
+	if !found {
+		return nil
+	}
+	metadataObj, ok := metadata.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("invalid patch, metadata must be an object")
+	}
+	for _, field := range []string{"name", "namespace", "uid", "resourceVersion", "generation", "creationTimestamp", "managedFields", "ownerReferences"} {
+		if _, found := metadataObj[field]; found {
+			return fmt.Errorf("patch must not touch field [metadata.%s]", field)
+		}
+	}
+	return nil
+}
+
+// trimCodeBlock removes the markdown code block which the model may wrap its output with.
+func trimCodeBlock(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+	s = strings.TrimPrefix(s, "```")
+	// language of code block, e.g. ```json
+	if i := strings.Index(s, "\n"); i >= 0 {
+		s = s[i+1:]
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "```"))
+}
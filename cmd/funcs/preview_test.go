@@ -0,0 +1,103 @@
+package funcs
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"testing"
+)
+
+func testDeployment(replicas int64) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":      "web",
+			"namespace": "default",
+			"managedFields": []interface{}{
+				map[string]interface{}{"manager": "kubectl", "operation": "Update"},
+			},
+		},
+		"spec": map[string]interface{}{
+			"replicas": replicas,
+		},
+	}}
+}
+
+func TestToYAML(t *testing.T) {
+	obj := testDeployment(3)
+	got, err := toYAML(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+    name: web
+    namespace: default
+spec:
+    replicas: 3
+`
+	if got != want {
+		t.Errorf("toYAML() =\n%s\nwant\n%s", got, want)
+	}
+	// the object itself is kept intact
+	if _, found, _ := unstructured.NestedSlice(obj.Object, "metadata", "managedFields"); !found {
+		t.Error("managedFields of the object are removed")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	got, err := diff(testDeployment(3), testDeployment(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// difflib keeps the empty line after the trailing newline as context
+	want := "--- live\n+++ updated\n@@ -4,5 +4,5 @@\n" +
+		"     name: web\n     namespace: default\n spec:\n-    replicas: 3\n+    replicas: 5\n \n"
+	if got != want {
+		t.Errorf("diff() =\n%s\nwant\n%s", got, want)
+	}
+
+	got, err = diff(testDeployment(3), testDeployment(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "" {
+		t.Errorf("diff of the same objects = %q, want empty", got)
+	}
+}
+
+func TestConfirmTurnedOff(t *testing.T) {
+	origin := Confirm
+	t.Cleanup(func() { Confirm = origin })
+	Confirm = false
+	if ok, err := confirm("Are you sure?"); !ok || err != nil {
+		t.Errorf("confirm() = %v, %v, want approved without asking", ok, err)
+	}
+}
+
+func TestValidatePatch(t *testing.T) {
+	tests := []struct {
+		patch   string
+		wantErr bool
+	}{
+		{`{"spec":{"replicas":3}}`, false},
+		{`{"metadata":{"labels":{"app":"nginx"},"annotations":{"a":"b"}}}`, false},
+		{`not json`, true},
+		{`[{"op":"replace","path":"/spec/replicas","value":3}]`, true},
+		{`{}`, true},
+		{`{"status":{"replicas":3}}`, true},
+		{`{"kind":"Service"}`, true},
+		{`{"apiVersion":"apps/v2"}`, true},
+		{`{"metadata":"nginx"}`, true},
+		{`{"metadata":{"name":"redis"}}`, true},
+		{`{"metadata":{"namespace":"prod"}}`, true},
+		{`{"metadata":{"resourceVersion":"1"}}`, true},
+		{`{"metadata":{"ownerReferences":null}}`, true},
+	}
+	for _, tt := range tests {
+		err := validatePatch([]byte(tt.patch))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validatePatch(%s) error = %v, want error %v", tt.patch, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,366 @@
+package funcs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/KokoiRuby/k8s-copilot/cmd/utils"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	restartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+	revisionAnnotation    = "deployment.kubernetes.io/revision"
+	changeCauseAnnotation = "kubernetes.io/change-cause"
+)
+
+// rolloutKinds are the workloads of group apps supporting rollout.
+var rolloutKinds = map[string]bool{"Deployment": true, "StatefulSet": true, "DaemonSet": true}
+
+// revision is a revision in rollout history, deployments keep it in ReplicaSets while others in ControllerRevisions.
+type revision struct {
+	number      int64
+	changeCause string
+	images      []string
+	// template of the ReplicaSet, for deployments
+	template *corev1.PodTemplateSpec
+	// data of the ControllerRevision, a strategic merge patch restoring the template
+	data []byte
+}
+
+// RolloutRestart restarts the pods of workload by bumping the restartedAt annotation of pod template, as kubectl rollout restart does.
+func RolloutRestart(ctx context.Context, namespace, resource, resourceName string, clientGo *utils.ClientGo) (string, error) {
+	ri, live, err := rolloutTarget(ctx, namespace, resource, resourceName, clientGo)
+	if err != nil {
+		return "", err
+	}
+	if paused, _, _ := unstructured.NestedBool(live.Object, "spec", "paused"); paused {
+		return "", fmt.Errorf("resource [%s] is paused, resume it before restart", resourceName)
+	}
+	patch := fmt.Sprintf(`{"spec":{"template":{"metadata":{"annotations":{%q:%q}}}}}`, restartedAtAnnotation, time.Now().Format(time.RFC3339))
+	return patchWithPreview(ctx, ri, live, types.StrategicMergePatchType, []byte(patch))
+}
+
+// RolloutStatus waits for the rollout to finish until timeout, the progress is printed as it changes.
+func RolloutStatus(ctx context.Context, namespace, resource, resourceName, timeout string, clientGo *utils.ClientGo) (string, error) {
+	d := waitTimeout
+	if timeout != "" {
+		var err error
+		d, err = time.ParseDuration(timeout)
+		if err != nil {
+			return "", fmt.Errorf("timeout [%s] is not a duration like 30s or 5m: %w", timeout, err)
+		}
+	}
+	ri, _, err := rolloutTarget(ctx, namespace, resource, resourceName, clientGo)
+	if err != nil {
+		return "", err
+	}
+
+	var status string
+	err = wait.PollUntilContextTimeout(ctx, waitInterval, d, true, func(ctx context.Context) (bool, error) {
+		obj, err := ri.Get(ctx, resourceName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		msg, done, err := rolloutStatus(obj)
+		if err != nil {
+			return false, err
+		}
+		if msg != status {
+			fmt.Println(msg)
+			status = msg
+		}
+		return done, nil
+	})
+	if wait.Interrupted(err) {
+		return fmt.Sprintf("%s\nRollout is still in progress after %s", status, d), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return status, nil
+}
+
+// rolloutStatus tells the progress of rollout & whether it's done, as the status viewers of kubectl rollout status do.
+func rolloutStatus(obj *unstructured.Unstructured) (string, bool, error) {
+	switch obj.GetKind() {
+	case "Deployment":
+		deploy := &appsv1.Deployment{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, deploy); err != nil {
+			return "", false, err
+		}
+		if deploy.Generation > deploy.Status.ObservedGeneration {
+			return "Waiting for deployment spec update to be observed...", false, nil
+		}
+		for _, c := range deploy.Status.Conditions {
+			if c.Type == appsv1.DeploymentProgressing && c.Reason == "ProgressDeadlineExceeded" {
+				return "", false, fmt.Errorf("deployment [%s] exceeded its progress deadline", deploy.Name)
+			}
+		}
+		status := deploy.Status
+		if deploy.Spec.Replicas != nil && status.UpdatedReplicas < *deploy.Spec.Replicas {
+			return fmt.Sprintf("Waiting for deployment [%s] rollout to finish: %d out of %d new replicas have been updated...", deploy.Name, status.UpdatedReplicas, *deploy.Spec.Replicas), false, nil
+		}
+		if status.Replicas > status.UpdatedReplicas {
+			return fmt.Sprintf("Waiting for deployment [%s] rollout to finish: %d old replicas are pending termination...", deploy.Name, status.Replicas-status.UpdatedReplicas), false, nil
+		}
+		if status.AvailableReplicas < status.UpdatedReplicas {
+			return fmt.Sprintf("Waiting for deployment [%s] rollout to finish: %d of %d updated replicas are available...", deploy.Name, status.AvailableReplicas, status.UpdatedReplicas), false, nil
+		}
+		return fmt.Sprintf("Deployment [%s] successfully rolled out", deploy.Name), true, nil
+	case "StatefulSet":
+		sts := &appsv1.StatefulSet{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, sts); err != nil {
+			return "", false, err
+		}
+		if sts.Spec.UpdateStrategy.Type == appsv1.OnDeleteStatefulSetStrategyType {
+			return "", false, fmt.Errorf("rollout status is only available for RollingUpdate strategy type")
+		}
+		status := sts.Status
+		if status.ObservedGeneration == 0 || sts.Generation > status.ObservedGeneration {
+			return "Waiting for statefulset spec update to be observed...", false, nil
+		}
+		if sts.Spec.Replicas != nil && status.ReadyReplicas < *sts.Spec.Replicas {
+			return fmt.Sprintf("Waiting for %d pods to be ready...", *sts.Spec.Replicas-status.ReadyReplicas), false, nil
+		}
+		if rollingUpdate := sts.Spec.UpdateStrategy.RollingUpdate; rollingUpdate != nil && rollingUpdate.Partition != nil && sts.Spec.Replicas != nil && *rollingUpdate.Partition > 0 {
+			if status.UpdatedReplicas < *sts.Spec.Replicas-*rollingUpdate.Partition {
+				return fmt.Sprintf("Waiting for partitioned roll out to finish: %d out of %d new pods have been updated...", status.UpdatedReplicas, *sts.Spec.Replicas-*rollingUpdate.Partition), false, nil
+			}
+			return fmt.Sprintf("Partitioned roll out complete: %d new pods have been updated...", status.UpdatedReplicas), true, nil
+		}
+		if status.UpdateRevision != status.CurrentRevision {
+			return fmt.Sprintf("Waiting for statefulset rolling update to complete %d pods at revision %s...", status.UpdatedReplicas, status.UpdateRevision), false, nil
+		}
+		return fmt.Sprintf("Statefulset rolling update complete %d pods at revision %s...", status.CurrentReplicas, status.CurrentRevision), true, nil
+	default:
+		ds := &appsv1.DaemonSet{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, ds); err != nil {
+			return "", false, err
+		}
+		if ds.Spec.UpdateStrategy.Type != appsv1.RollingUpdateDaemonSetStrategyType {
+			return "", false, fmt.Errorf("rollout status is only available for RollingUpdate strategy type")
+		}
+		if ds.Generation > ds.Status.ObservedGeneration {
+			return "Waiting for daemon set spec update to be observed...", false, nil
+		}
+		status := ds.Status
+		if status.UpdatedNumberScheduled < status.DesiredNumberScheduled {
+			return fmt.Sprintf("Waiting for daemon set [%s] rollout to finish: %d out of %d new pods have been updated...", ds.Name, status.UpdatedNumberScheduled, status.DesiredNumberScheduled), false, nil
+		}
+		if status.NumberAvailable < status.DesiredNumberScheduled {
+			return fmt.Sprintf("Waiting for daemon set [%s] rollout to finish: %d of %d updated pods are available...", ds.Name, status.NumberAvailable, status.DesiredNumberScheduled), false, nil
+		}
+		return fmt.Sprintf("Daemon set [%s] successfully rolled out", ds.Name), true, nil
+	}
+}
+
+// RolloutHistory lists the revisions of workload with change causes & images.
+func RolloutHistory(ctx context.Context, namespace, resource, resourceName string, clientGo *utils.ClientGo) (string, error) {
+	_, live, err := rolloutTarget(ctx, namespace, resource, resourceName, clientGo)
+	if err != nil {
+		return "", err
+	}
+	revisions, err := rolloutHistory(ctx, live, clientGo)
+	if err != nil {
+		return "", err
+	}
+	if len(revisions) == 0 {
+		return fmt.Sprintf("No rollout history found for resource [%s]", resourceName), nil
+	}
+
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "REVISION\tCHANGE-CAUSE\tIMAGES")
+	for i, r := range revisions {
+		number := strconv.FormatInt(r.number, 10)
+		if i == len(revisions)-1 {
+			number += " (current)"
+		}
+		changeCause := r.changeCause
+		if changeCause == "" {
+			changeCause = "<none>"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", number, changeCause, strings.Join(r.images, ","))
+	}
+	_ = w.Flush()
+
+	result := b.String()
+	fmt.Print(result)
+	return result, nil
+}
+
+// RolloutUndo rolls back the workload to the revision, or to the previous one if revision is 0.
+func RolloutUndo(ctx context.Context, namespace, resource, resourceName string, toRevision int64, clientGo *utils.ClientGo) (string, error) {
+	ri, live, err := rolloutTarget(ctx, namespace, resource, resourceName, clientGo)
+	if err != nil {
+		return "", err
+	}
+	if paused, _, _ := unstructured.NestedBool(live.Object, "spec", "paused"); paused {
+		return "", fmt.Errorf("resource [%s] is paused, resume it before rollback", resourceName)
+	}
+	revisions, err := rolloutHistory(ctx, live, clientGo)
+	if err != nil {
+		return "", err
+	}
+
+	target, err := undoRevision(revisions, toRevision, resourceName)
+	if err != nil {
+		return "", err
+	}
+	if current := revisions[len(revisions)-1]; target.number == current.number {
+		return fmt.Sprintf("Resource [%s] is already at revision %d", resourceName, current.number), nil
+	}
+
+	fmt.Printf("Rolling back resource [%s] to revision %d, images: %s\n", resourceName, target.number, strings.Join(target.images, ","))
+	patchType, patch, err := undoPatch(live, target)
+	if err != nil {
+		return "", err
+	}
+	return patchWithPreview(ctx, ri, live, patchType, patch)
+}
+
+// undoRevision picks the revision to roll back to, the previous one if toRevision is 0.
+func undoRevision(revisions []revision, toRevision int64, resourceName string) (*revision, error) {
+	if toRevision == 0 {
+		if len(revisions) < 2 {
+			return nil, fmt.Errorf("resource [%s] has no previous revision to roll back to", resourceName)
+		}
+		return &revisions[len(revisions)-2], nil
+	}
+	var numbers []string
+	for i := range revisions {
+		if revisions[i].number == toRevision {
+			return &revisions[i], nil
+		}
+		numbers = append(numbers, strconv.FormatInt(revisions[i].number, 10))
+	}
+	return nil, fmt.Errorf("revision [%d] of resource [%s] not found, revisions: %s", toRevision, resourceName, strings.Join(numbers, ", "))
+}
+
+// undoPatch restores the template of the revision. For deployments, the change cause of the ReplicaSet is copied
+// to the deployment as kubectl rollout undo does.
+func undoPatch(live *unstructured.Unstructured, target *revision) (types.PatchType, []byte, error) {
+	if target.template == nil {
+		return types.StrategicMergePatchType, target.data, nil
+	}
+	// replace the template with the one of ReplicaSet, except the label added by the deployment controller
+	template := target.template.DeepCopy()
+	delete(template.Labels, appsv1.DefaultDeploymentUniqueLabelKey)
+	ops := []map[string]interface{}{{"op": "replace", "path": "/spec/template", "value": template}}
+
+	annotations := live.GetAnnotations()
+	changeCausePath := "/metadata/annotations/" + strings.ReplaceAll(changeCauseAnnotation, "/", "~1")
+	switch _, ok := annotations[changeCauseAnnotation]; {
+	case target.changeCause != "" && annotations == nil:
+		ops = append(ops, map[string]interface{}{"op": "add", "path": "/metadata/annotations", "value": map[string]string{changeCauseAnnotation: target.changeCause}})
+	case target.changeCause != "":
+		ops = append(ops, map[string]interface{}{"op": "add", "path": changeCausePath, "value": target.changeCause})
+	case ok:
+		ops = append(ops, map[string]interface{}{"op": "remove", "path": changeCausePath})
+	}
+	patch, err := json.Marshal(ops)
+	if err != nil {
+		return "", nil, err
+	}
+	return types.JSONPatchType, patch, nil
+}
+
+// rolloutTarget gets the workload to roll out.
+func rolloutTarget(ctx context.Context, namespace, resource, resourceName string, clientGo *utils.ClientGo) (dynamic.ResourceInterface, *unstructured.Unstructured, error) {
+	res, err := clientGo.ResolveResource(resource)
+	if err != nil {
+		return nil, nil, err
+	}
+	if gvk := res.GroupVersionKind; gvk.Group != appsv1.GroupName || !rolloutKinds[gvk.Kind] {
+		return nil, nil, fmt.Errorf("rollout not supported for resource [%s], supported: deployments, statefulsets, daemonsets", res.Resource.GroupResource())
+	}
+	ri, err := clientGo.ResourceInterface(res, namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+	live, err := ri.Get(ctx, resourceName, metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return ri, live, nil
+}
+
+// rolloutHistory collects revisions of the workload from ReplicaSets or ControllerRevisions it controls, oldest first.
+func rolloutHistory(ctx context.Context, live *unstructured.Unstructured, clientGo *utils.ClientGo) ([]revision, error) {
+	selector, err := podSelector(live)
+	if err != nil {
+		return nil, err
+	}
+	opts := metav1.ListOptions{LabelSelector: selector}
+
+	var revisions []revision
+	if live.GetKind() == "Deployment" {
+		rsList, err := clientGo.ClientSet.AppsV1().ReplicaSets(live.GetNamespace()).List(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		for i := range rsList.Items {
+			rs := &rsList.Items[i]
+			number, err := strconv.ParseInt(rs.Annotations[revisionAnnotation], 10, 64)
+			if !metav1.IsControlledBy(rs, live) || err != nil {
+				continue
+			}
+			revisions = append(revisions, revision{
+				number:      number,
+				changeCause: rs.Annotations[changeCauseAnnotation],
+				images:      images(rs.Spec.Template.Spec),
+				template:    &rs.Spec.Template,
+			})
+		}
+	} else {
+		crList, err := clientGo.ClientSet.AppsV1().ControllerRevisions(live.GetNamespace()).List(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		for i := range crList.Items {
+			cr := &crList.Items[i]
+			if !metav1.IsControlledBy(cr, live) {
+				continue
+			}
+			data := struct {
+				Spec struct {
+					Template corev1.PodTemplateSpec `json:"template"`
+				} `json:"spec"`
+			}{}
+			if err := json.Unmarshal(cr.Data.Raw, &data); err != nil {
+				return nil, err
+			}
+			revisions = append(revisions, revision{
+				number:      cr.Revision,
+				changeCause: cr.Annotations[changeCauseAnnotation],
+				images:      images(data.Spec.Template.Spec),
+				data:        cr.Data.Raw,
+			})
+		}
+	}
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].number < revisions[j].number
+	})
+	return revisions, nil
+}
+
+func images(spec corev1.PodSpec) []string {
+	var images []string
+	for _, c := range spec.Containers {
+		images = append(images, c.Image)
+	}
+	return images
+}
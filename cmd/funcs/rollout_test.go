@@ -0,0 +1,274 @@
+package funcs
+
+import (
+	"encoding/json"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"reflect"
+	"testing"
+)
+
+// testWorkload builds a workload of group apps with the given spec & status.
+func testWorkload(kind string, generation int64, spec, status map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       kind,
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "default", "generation": generation},
+		"spec":       spec,
+		"status":     status,
+	}}
+}
+
+func TestRolloutStatus(t *testing.T) {
+	replicas := map[string]interface{}{"replicas": int64(3)}
+	rollingUpdate := map[string]interface{}{"replicas": int64(3), "updateStrategy": map[string]interface{}{"type": "RollingUpdate"}}
+	partitioned := map[string]interface{}{"replicas": int64(3), "updateStrategy": map[string]interface{}{
+		"type": "RollingUpdate", "rollingUpdate": map[string]interface{}{"partition": int64(1)},
+	}}
+	onDelete := map[string]interface{}{"updateStrategy": map[string]interface{}{"type": "OnDelete"}}
+
+	tests := []struct {
+		name     string
+		obj      *unstructured.Unstructured
+		want     string
+		wantDone bool
+		wantErr  bool
+	}{
+		{
+			name: "deployment spec not observed",
+			obj:  testWorkload("Deployment", 2, replicas, map[string]interface{}{"observedGeneration": int64(1)}),
+			want: "Waiting for deployment spec update to be observed...",
+		},
+		{
+			name: "deployment progress deadline exceeded",
+			obj: testWorkload("Deployment", 1, replicas, map[string]interface{}{"observedGeneration": int64(1), "conditions": []interface{}{
+				map[string]interface{}{"type": "Progressing", "status": "False", "reason": "ProgressDeadlineExceeded"},
+			}}),
+			wantErr: true,
+		},
+		{
+			name: "deployment replicas being updated",
+			obj:  testWorkload("Deployment", 1, replicas, map[string]interface{}{"observedGeneration": int64(1), "replicas": int64(3), "updatedReplicas": int64(1)}),
+			want: "Waiting for deployment [web] rollout to finish: 1 out of 3 new replicas have been updated...",
+		},
+		{
+			name: "deployment old replicas pending termination",
+			obj:  testWorkload("Deployment", 1, replicas, map[string]interface{}{"observedGeneration": int64(1), "replicas": int64(4), "updatedReplicas": int64(3)}),
+			want: "Waiting for deployment [web] rollout to finish: 1 old replicas are pending termination...",
+		},
+		{
+			name: "deployment updated replicas not available",
+			obj: testWorkload("Deployment", 1, replicas, map[string]interface{}{
+				"observedGeneration": int64(1), "replicas": int64(3), "updatedReplicas": int64(3), "availableReplicas": int64(2),
+			}),
+			want: "Waiting for deployment [web] rollout to finish: 2 of 3 updated replicas are available...",
+		},
+		{
+			name: "deployment rolled out",
+			obj: testWorkload("Deployment", 1, replicas, map[string]interface{}{
+				"observedGeneration": int64(1), "replicas": int64(3), "updatedReplicas": int64(3), "availableReplicas": int64(3),
+			}),
+			want:     "Deployment [web] successfully rolled out",
+			wantDone: true,
+		},
+		{
+			name:    "statefulset on delete",
+			obj:     testWorkload("StatefulSet", 1, onDelete, map[string]interface{}{"observedGeneration": int64(1)}),
+			wantErr: true,
+		},
+		{
+			name: "statefulset spec not observed",
+			obj:  testWorkload("StatefulSet", 1, rollingUpdate, map[string]interface{}{}),
+			want: "Waiting for statefulset spec update to be observed...",
+		},
+		{
+			name: "statefulset pods not ready",
+			obj:  testWorkload("StatefulSet", 1, rollingUpdate, map[string]interface{}{"observedGeneration": int64(1), "readyReplicas": int64(1)}),
+			want: "Waiting for 2 pods to be ready...",
+		},
+		{
+			name: "statefulset partitioned roll out",
+			obj:  testWorkload("StatefulSet", 1, partitioned, map[string]interface{}{"observedGeneration": int64(1), "readyReplicas": int64(3), "updatedReplicas": int64(1)}),
+			want: "Waiting for partitioned roll out to finish: 1 out of 2 new pods have been updated...",
+		},
+		{
+			name:     "statefulset partitioned roll out complete",
+			obj:      testWorkload("StatefulSet", 1, partitioned, map[string]interface{}{"observedGeneration": int64(1), "readyReplicas": int64(3), "updatedReplicas": int64(2)}),
+			want:     "Partitioned roll out complete: 2 new pods have been updated...",
+			wantDone: true,
+		},
+		{
+			name: "statefulset rolling update",
+			obj: testWorkload("StatefulSet", 1, rollingUpdate, map[string]interface{}{
+				"observedGeneration": int64(1), "readyReplicas": int64(3), "updatedReplicas": int64(2), "currentRevision": "web-1", "updateRevision": "web-2",
+			}),
+			want: "Waiting for statefulset rolling update to complete 2 pods at revision web-2...",
+		},
+		{
+			name: "statefulset rolling update complete",
+			obj: testWorkload("StatefulSet", 1, rollingUpdate, map[string]interface{}{
+				"observedGeneration": int64(1), "readyReplicas": int64(3), "currentReplicas": int64(3), "currentRevision": "web-2", "updateRevision": "web-2",
+			}),
+			want:     "Statefulset rolling update complete 3 pods at revision web-2...",
+			wantDone: true,
+		},
+		{
+			name:    "daemon set on delete",
+			obj:     testWorkload("DaemonSet", 1, onDelete, map[string]interface{}{"observedGeneration": int64(1)}),
+			wantErr: true,
+		},
+		{
+			name: "daemon set spec not observed",
+			obj:  testWorkload("DaemonSet", 2, rollingUpdate, map[string]interface{}{"observedGeneration": int64(1)}),
+			want: "Waiting for daemon set spec update to be observed...",
+		},
+		{
+			name: "daemon set pods being updated",
+			obj:  testWorkload("DaemonSet", 1, rollingUpdate, map[string]interface{}{"observedGeneration": int64(1), "desiredNumberScheduled": int64(3), "updatedNumberScheduled": int64(1)}),
+			want: "Waiting for daemon set [web] rollout to finish: 1 out of 3 new pods have been updated...",
+		},
+		{
+			name: "daemon set updated pods not available",
+			obj: testWorkload("DaemonSet", 1, rollingUpdate, map[string]interface{}{
+				"observedGeneration": int64(1), "desiredNumberScheduled": int64(3), "updatedNumberScheduled": int64(3), "numberAvailable": int64(2),
+			}),
+			want: "Waiting for daemon set [web] rollout to finish: 2 of 3 updated pods are available...",
+		},
+		{
+			name: "daemon set rolled out",
+			obj: testWorkload("DaemonSet", 1, rollingUpdate, map[string]interface{}{
+				"observedGeneration": int64(1), "desiredNumberScheduled": int64(3), "updatedNumberScheduled": int64(3), "numberAvailable": int64(3),
+			}),
+			want:     "Daemon set [web] successfully rolled out",
+			wantDone: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, done, err := rolloutStatus(tt.obj)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("rolloutStatus() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want || done != tt.wantDone {
+				t.Errorf("rolloutStatus() = %q, %v, want %q, %v", got, done, tt.want, tt.wantDone)
+			}
+		})
+	}
+}
+
+func TestUndoRevision(t *testing.T) {
+	revisions := []revision{{number: 1}, {number: 3}, {number: 4}}
+	tests := []struct {
+		name       string
+		revisions  []revision
+		toRevision int64
+		want       int64
+		wantErr    string
+	}{
+		{name: "previous", revisions: revisions, want: 3},
+		{name: "given", revisions: revisions, toRevision: 1, want: 1},
+		{name: "current", revisions: revisions, toRevision: 4, want: 4},
+		{name: "not found", revisions: revisions, toRevision: 2, wantErr: "revision [2] of resource [web] not found, revisions: 1, 3, 4"},
+		{name: "no previous", revisions: revisions[:1], wantErr: "resource [web] has no previous revision to roll back to"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := undoRevision(tt.revisions, tt.toRevision, "web")
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("undoRevision() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("undoRevision() error = %v", err)
+			}
+			if got.number != tt.want {
+				t.Errorf("undoRevision() = %d, want %d", got.number, tt.want)
+			}
+		})
+	}
+}
+
+func TestUndoPatch(t *testing.T) {
+	template := &corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web", "pod-template-hash": "5d4f"}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "web", Image: "nginx:1.26"}}},
+	}
+	wantTemplate := map[string]interface{}{
+		"metadata": map[string]interface{}{"creationTimestamp": nil, "labels": map[string]interface{}{"app": "web"}},
+		"spec":     map[string]interface{}{"containers": []interface{}{map[string]interface{}{"name": "web", "image": "nginx:1.26", "resources": map[string]interface{}{}}}},
+	}
+	replaceTemplate := map[string]interface{}{"op": "replace", "path": "/spec/template", "value": wantTemplate}
+	changeCausePath := "/metadata/annotations/kubernetes.io~1change-cause"
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		target      revision
+		wantType    types.PatchType
+		want        interface{}
+	}{
+		{
+			name:     "change cause copied",
+			target:   revision{template: template, changeCause: "kubectl set image deploy/web web=nginx:1.26"},
+			wantType: types.JSONPatchType,
+			want: []interface{}{replaceTemplate, map[string]interface{}{
+				"op": "add", "path": "/metadata/annotations", "value": map[string]interface{}{"kubernetes.io/change-cause": "kubectl set image deploy/web web=nginx:1.26"},
+			}},
+		},
+		{
+			name:        "change cause replaced",
+			annotations: map[string]string{"kubernetes.io/change-cause": "kubectl set image deploy/web web=nginx:1.27"},
+			target:      revision{template: template, changeCause: "kubectl set image deploy/web web=nginx:1.26"},
+			wantType:    types.JSONPatchType,
+			want: []interface{}{replaceTemplate, map[string]interface{}{
+				"op": "add", "path": changeCausePath, "value": "kubectl set image deploy/web web=nginx:1.26",
+			}},
+		},
+		{
+			name:        "change cause removed",
+			annotations: map[string]string{"kubernetes.io/change-cause": "kubectl set image deploy/web web=nginx:1.27"},
+			target:      revision{template: template},
+			wantType:    types.JSONPatchType,
+			want:        []interface{}{replaceTemplate, map[string]interface{}{"op": "remove", "path": changeCausePath}},
+		},
+		{
+			name:        "no change cause",
+			annotations: map[string]string{"deployment.kubernetes.io/revision": "2"},
+			target:      revision{template: template},
+			wantType:    types.JSONPatchType,
+			want:        []interface{}{replaceTemplate},
+		},
+		{
+			name:     "controller revision",
+			target:   revision{data: []byte(`{"spec":{"template":{"spec":{"containers":[{"name":"web","image":"nginx:1.26"}]}}}}`)},
+			wantType: types.StrategicMergePatchType,
+			want: map[string]interface{}{"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{
+				"containers": []interface{}{map[string]interface{}{"name": "web", "image": "nginx:1.26"}},
+			}}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			live := testWorkload("Deployment", 1, nil, nil)
+			live.SetAnnotations(tt.annotations)
+			patchType, patch, err := undoPatch(live, &tt.target)
+			if err != nil {
+				t.Fatalf("undoPatch() error = %v", err)
+			}
+			var got interface{}
+			if err := json.Unmarshal(patch, &got); err != nil {
+				t.Fatalf("undoPatch() patch %s: %v", patch, err)
+			}
+			if patchType != tt.wantType || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("undoPatch() = %s %s, want %s %v", patchType, patch, tt.wantType, tt.want)
+			}
+		})
+	}
+	if template.Labels["pod-template-hash"] == "" {
+		t.Error("undoPatch() modified the template of revision")
+	}
+}
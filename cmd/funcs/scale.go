@@ -0,0 +1,123 @@
+package funcs
+
+import (
+	"context"
+	"fmt"
+	"github.com/KokoiRuby/k8s-copilot/cmd/utils"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"time"
+)
+
+const (
+	// scaleSubresource is served by deployments, statefulsets, replicasets & CRDs enabling it.
+	scaleSubresource = "scale"
+	// waitInterval & waitTimeout bound waiting for replicas to be ready.
+	waitInterval = 2 * time.Second
+	waitTimeout  = 3 * time.Minute
+)
+
+// readyReplicasKinds expose status.readyReplicas, which is omitted while none of the replicas is ready.
+var readyReplicasKinds = map[schema.GroupKind]bool{
+	{Group: "apps", Kind: "Deployment"}:  true,
+	{Group: "apps", Kind: "ReplicaSet"}:  true,
+	{Group: "apps", Kind: "StatefulSet"}: true,
+}
+
+// ScaleResource scales the resource by its scale subresource, without round-tripping the manifest through LLM.
+// It waits for the replicas to be ready if wait is set.
+func ScaleResource(ctx context.Context, namespace, resource, resourceName string, replicas int64, wait bool, clientGo *utils.ClientGo) (string, error) {
+	if replicas < 0 {
+		return "", fmt.Errorf("replicas must not be negative, got %d", replicas)
+	}
+	res, err := clientGo.ResolveResource(resource)
+	if err != nil {
+		return "", err
+	}
+	ri, err := clientGo.ResourceInterface(res, namespace)
+	if err != nil {
+		return "", err
+	}
+
+	scale, err := ri.Get(ctx, resourceName, metav1.GetOptions{}, scaleSubresource)
+	if apierrors.IsNotFound(err) {
+		// either the resource or its scale subresource is missing
+		if _, getErr := ri.Get(ctx, resourceName, metav1.GetOptions{}); getErr == nil {
+			return "", fmt.Errorf("resource [%s] has no scale subresource", res.Resource.GroupResource())
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	old, _, _ := unstructured.NestedInt64(scale.Object, "spec", "replicas")
+	if old == replicas {
+		return fmt.Sprintf("Resource [%s] already has %d replicas", resourceName, replicas), nil
+	}
+
+	patch := []byte(fmt.Sprintf(`{"spec":{"replicas":%d}}`, replicas))
+	_, err = ri.Patch(ctx, resourceName, types.MergePatchType, patch, metav1.PatchOptions{FieldManager: FieldManager, DryRun: dryRunAll}, scaleSubresource)
+	if err != nil {
+		return "", err
+	}
+	if DryRun {
+		return fmt.Sprintf("Dry run: resource [%s] would be scaled from %d to %d replicas, nothing is applied", resourceName, old, replicas), nil
+	}
+
+	ok, err := confirm(fmt.Sprintf("Are you sure that you want to scale the resource [%s] from %d to %d replicas?", resourceName, old, replicas))
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "Scaling aborted by user.", nil
+	}
+	_, err = ri.Patch(ctx, resourceName, types.MergePatchType, patch, metav1.PatchOptions{FieldManager: FieldManager}, scaleSubresource)
+	if err != nil {
+		return "", err
+	}
+	result := fmt.Sprintf("Resource [%s] scaled from %d to %d replicas", resourceName, old, replicas)
+	if !wait {
+		return result, nil
+	}
+
+	fmt.Printf("%s, waiting for the replicas to be ready...\n", result)
+	return result + ", " + waitForReplicas(ctx, ri, resourceName, replicas), nil
+}
+
+// waitForReplicas waits until the scale subresource reports the expected replicas & they are ready, then tells how
+// many of them are ready. Resources not exposing status.readyReplicas are only waited for the replicas.
+func waitForReplicas(ctx context.Context, ri dynamic.ResourceInterface, name string, replicas int64) string {
+	var current, ready int64
+	readiness := true
+	err := wait.PollUntilContextTimeout(ctx, waitInterval, waitTimeout, true, func(ctx context.Context) (bool, error) {
+		scale, err := ri.Get(ctx, name, metav1.GetOptions{}, scaleSubresource)
+		if err != nil {
+			return false, err
+		}
+		obj, err := ri.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		// the status may be stale before the controller observes the change
+		if observed, ok, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration"); ok && observed < obj.GetGeneration() {
+			return false, nil
+		}
+		current, _, _ = unstructured.NestedInt64(scale.Object, "status", "replicas")
+		ready, readiness, _ = unstructured.NestedInt64(obj.Object, "status", "readyReplicas")
+		// readyReplicas of built-in workloads is omitted while none is ready
+		readiness = readiness || readyReplicasKinds[obj.GroupVersionKind().GroupKind()]
+		return current == replicas && (!readiness || ready == replicas), nil
+	})
+	status := fmt.Sprintf("%d/%d replicas ready", ready, replicas)
+	if !readiness {
+		status = fmt.Sprintf("%d/%d replicas, readiness is not reported by the resource", current, replicas)
+	}
+	if err != nil {
+		return fmt.Sprintf("%s, stopped waiting: %v", status, err)
+	}
+	return status
+}
@@ -0,0 +1,112 @@
+package funcs
+
+import (
+	"context"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWaitForReplicas(t *testing.T) {
+	tests := []struct {
+		name   string
+		gvr    schema.GroupVersionResource
+		kind   string
+		status map[string]interface{}
+		scaled int64
+		want   string
+		// replicas to wait for
+		replicas int64
+	}{
+		{
+			name:     "ready",
+			gvr:      schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+			kind:     "Deployment",
+			status:   map[string]interface{}{"observedGeneration": int64(2), "replicas": int64(3), "readyReplicas": int64(3)},
+			replicas: 3,
+			scaled:   3,
+			want:     "3/3 replicas ready",
+		},
+		{
+			name:     "not ready yet",
+			gvr:      schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+			kind:     "Deployment",
+			status:   map[string]interface{}{"observedGeneration": int64(2), "replicas": int64(3), "readyReplicas": int64(1)},
+			replicas: 3,
+			scaled:   3,
+			want:     "1/3 replicas ready, stopped waiting",
+		},
+		{
+			name:     "none ready of built-in workloads",
+			gvr:      schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "statefulsets"},
+			kind:     "StatefulSet",
+			status:   map[string]interface{}{"observedGeneration": int64(2), "replicas": int64(3)},
+			replicas: 3,
+			scaled:   3,
+			want:     "0/3 replicas ready, stopped waiting",
+		},
+		{
+			name:     "stale status",
+			gvr:      schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+			kind:     "Deployment",
+			status:   map[string]interface{}{"observedGeneration": int64(1), "replicas": int64(3), "readyReplicas": int64(3)},
+			replicas: 3,
+			scaled:   3,
+			want:     "0/3 replicas ready, stopped waiting",
+		},
+		{
+			name:     "readiness not exposed",
+			gvr:      schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "workers"},
+			kind:     "Worker",
+			status:   map[string]interface{}{"replicas": int64(2)},
+			replicas: 2,
+			scaled:   2,
+			want:     "2/2 replicas, readiness is not reported by the resource",
+		},
+		{
+			name:     "replicas not reached without readiness",
+			gvr:      schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "workers"},
+			kind:     "Worker",
+			status:   map[string]interface{}{"replicas": int64(2)},
+			replicas: 5,
+			scaled:   2,
+			want:     "2/5 replicas, readiness is not reported by the resource, stopped waiting",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": tt.gvr.GroupVersion().String(),
+				"kind":       tt.kind,
+				"metadata":   map[string]interface{}{"name": "web", "namespace": "default", "generation": int64(2)},
+				"status":     tt.status,
+			}}
+			dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+				map[schema.GroupVersionResource]string{tt.gvr: tt.kind + "List"}, obj)
+			// the scale subresource reports the replicas observed by the controller
+			dynamicClient.PrependReactor("get", tt.gvr.Resource, func(action clienttesting.Action) (bool, runtime.Object, error) {
+				if action.GetSubresource() != scaleSubresource {
+					return false, nil, nil
+				}
+				return true, &unstructured.Unstructured{Object: map[string]interface{}{
+					"apiVersion": "autoscaling/v1",
+					"kind":       "Scale",
+					"metadata":   map[string]interface{}{"name": "web", "namespace": "default"},
+					"status":     map[string]interface{}{"replicas": tt.scaled},
+				}}, nil
+			})
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			ri := dynamicClient.Resource(tt.gvr).Namespace("default")
+			if got := waitForReplicas(ctx, ri, "web", tt.replicas); !strings.HasPrefix(got, tt.want) {
+				t.Errorf("waitForReplicas() = %q, want prefix %q", got, tt.want)
+			}
+		})
+	}
+}
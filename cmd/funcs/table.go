@@ -0,0 +1,83 @@
+package funcs
+
+import (
+	"encoding/json"
+	"fmt"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// printTable renders the Table as kubectl get does, only columns of priority 0 are printed unless wide.
+// The NAMESPACE column is prepended across all namespaces.
+func printTable(table *metav1.Table, wide, withNamespace bool) string {
+	var columns []int
+	for i, column := range table.ColumnDefinitions {
+		if wide || column.Priority == 0 {
+			columns = append(columns, i)
+		}
+	}
+
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 3, ' ', 0)
+	var headers []string
+	if withNamespace {
+		headers = append(headers, "NAMESPACE")
+	}
+	for _, i := range columns {
+		headers = append(headers, strings.ToUpper(table.ColumnDefinitions[i].Name))
+	}
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+	for _, row := range table.Rows {
+		var cells []string
+		if withNamespace {
+			cells = append(cells, rowNamespace(row))
+		}
+		for _, i := range columns {
+			var cell interface{}
+			if i < len(row.Cells) {
+				cell = row.Cells[i]
+			}
+			cells = append(cells, formatCell(table.ColumnDefinitions[i], cell))
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+	_ = w.Flush()
+	return b.String()
+}
+
+// rowNamespace gets the namespace from the metadata of the row object.
+func rowNamespace(row metav1.TableRow) string {
+	obj := metav1.PartialObjectMetadata{}
+	if err := json.Unmarshal(row.Object.Raw, &obj); err != nil || obj.Namespace == "" {
+		return "<none>"
+	}
+	return obj.Namespace
+}
+
+// formatCell formats the cell by its column, timestamps are shown as ages like 5m or 3d.
+func formatCell(column metav1.TableColumnDefinition, cell interface{}) string {
+	switch v := cell.(type) {
+	case nil:
+		return "<none>"
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		if column.Format == "date" {
+			if t, err := time.Parse(time.RFC3339, v); err == nil {
+				return age(t)
+			}
+		}
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// age formats the time elapsed since t as kubectl does, e.g. 5m or 3d.
+func age(t time.Time) string {
+	return duration.HumanDuration(time.Since(t))
+}
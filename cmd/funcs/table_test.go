@@ -0,0 +1,102 @@
+package funcs
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"testing"
+	"time"
+)
+
+// testTable is a Table of pods as served by the API server, cells decoded from JSON.
+func testTable() *metav1.Table {
+	created := time.Now().Add(-72 * time.Hour).UTC().Format(time.RFC3339)
+	return &metav1.Table{
+		ColumnDefinitions: []metav1.TableColumnDefinition{
+			{Name: "Name", Type: "string", Format: "name"},
+			{Name: "Ready", Type: "string"},
+			{Name: "Restarts", Type: "integer"},
+			{Name: "Age", Type: "string", Format: "date"},
+			{Name: "IP", Type: "string", Priority: 1},
+		},
+		Rows: []metav1.TableRow{
+			{
+				Cells:  []interface{}{"web-0", "1/1", float64(0), created, "10.0.0.1"},
+				Object: runtime.RawExtension{Raw: []byte(`{"kind":"PartialObjectMetadata","metadata":{"name":"web-0","namespace":"default"}}`)},
+			},
+			{
+				Cells:  []interface{}{"redis-0", "0/1", float64(12), created, nil},
+				Object: runtime.RawExtension{Raw: []byte(`{"kind":"PartialObjectMetadata","metadata":{"name":"redis-0","namespace":"cache"}}`)},
+			},
+			{
+				// cells may be missing
+				Cells: []interface{}{"job-0", "0/1"},
+			},
+		},
+	}
+}
+
+func TestPrintTable(t *testing.T) {
+	tests := []struct {
+		name          string
+		wide          bool
+		withNamespace bool
+		want          string
+	}{
+		{
+			name: "priority columns are hidden",
+			want: `NAME      READY   RESTARTS   AGE
+web-0     1/1     0          3d
+redis-0   0/1     12         3d
+job-0     0/1     <none>     <none>
+`,
+		},
+		{
+			name: "wide shows all columns",
+			wide: true,
+			want: `NAME      READY   RESTARTS   AGE      IP
+web-0     1/1     0          3d       10.0.0.1
+redis-0   0/1     12         3d       <none>
+job-0     0/1     <none>     <none>   <none>
+`,
+		},
+		{
+			name:          "namespace column across all namespaces",
+			withNamespace: true,
+			want: `NAMESPACE   NAME      READY   RESTARTS   AGE
+default     web-0     1/1     0          3d
+cache       redis-0   0/1     12         3d
+<none>      job-0     0/1     <none>     <none>
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := printTable(testTable(), tt.wide, tt.withNamespace); got != tt.want {
+				t.Errorf("printTable() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatCell(t *testing.T) {
+	date := metav1.TableColumnDefinition{Name: "Age", Type: "string", Format: "date"}
+	str := metav1.TableColumnDefinition{Name: "Status", Type: "string"}
+	tests := []struct {
+		column metav1.TableColumnDefinition
+		cell   interface{}
+		want   string
+	}{
+		{str, nil, "<none>"},
+		{str, "Running", "Running"},
+		{str, float64(3), "3"},
+		{str, 0.5, "0.5"},
+		{str, true, "true"},
+		{date, time.Now().Add(-10 * time.Minute).UTC().Format(time.RFC3339), "10m"},
+		{date, "not a time", "not a time"},
+	}
+	for _, tt := range tests {
+		if got := formatCell(tt.column, tt.cell); got != tt.want {
+			t.Errorf("formatCell(%v) = %q, want %q", tt.cell, got, tt.want)
+		}
+	}
+}
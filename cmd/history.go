@@ -0,0 +1,79 @@
+/*
+Copyright © 2024 KokoiRuby kokoiruby@gmail.com
+*/
+package cmd
+
+import (
+	"github.com/KokoiRuby/k8s-copilot/cmd/utils"
+)
+
+// history keeps the dialogue of a chat session across queries,
+// so that follow-ups like "now delete it" can refer to previous turns.
+type history struct {
+	// maxTokens is the rough token budget of the dialogue sent to the model.
+	maxTokens int
+	messages  []utils.Message
+}
+
+func newHistory(maxTokens int) *history {
+	return &history{maxTokens: maxTokens}
+}
+
+// add appends messages to the history.
+func (h *history) add(msgs ...utils.Message) {
+	h.messages = append(h.messages, msgs...)
+}
+
+// reset forgets all previous turns.
+func (h *history) reset() {
+	h.messages = nil
+}
+
+// dialogue returns the system prompt followed by the remembered messages.
+func (h *history) dialogue() []utils.Message {
+	dialogue := []utils.Message{
+		{
+			Role:    utils.RoleSystem,
+			Content: sysPrompt,
+		},
+	}
+	return append(dialogue, h.messages...)
+}
+
+// trim drops the oldest turns until the history fits into the token budget.
+// A turn starts with a user message, it's dropped as a whole so that tool results
+// never lose the assistant message which issued the tool calls. The latest turn is always kept.
+func (h *history) trim() {
+	budget := h.maxTokens - estimateTokens(utils.Message{Content: sysPrompt})
+	for h.tokens() > budget {
+		next := -1
+		for i := 1; i < len(h.messages); i++ {
+			if h.messages[i].Role == utils.RoleUser {
+				next = i
+				break
+			}
+		}
+		if next == -1 {
+			return
+		}
+		h.messages = h.messages[next:]
+	}
+}
+
+func (h *history) tokens() int {
+	total := 0
+	for _, msg := range h.messages {
+		total += estimateTokens(msg)
+	}
+	return total
+}
+
+// estimateTokens roughly estimates tokens of a message, 1 token ≈ 4 chars in English,
+// plus a few tokens of overhead per message.
+func estimateTokens(msg utils.Message) int {
+	chars := len(msg.Content)
+	for _, toolCall := range msg.ToolCalls {
+		chars += len(toolCall.Name) + len(toolCall.Arguments)
+	}
+	return chars/4 + 4
+}
@@ -0,0 +1,58 @@
+/*
+Copyright © 2024 KokoiRuby kokoiruby@gmail.com
+*/
+package cmd
+
+import (
+	"github.com/KokoiRuby/k8s-copilot/cmd/utils"
+	"strings"
+	"testing"
+)
+
+// turn is a query calling a tool, 4 messages of 29 tokens each.
+func turn(query string) []utils.Message {
+	content := strings.Repeat("x", 100)
+	return []utils.Message{
+		{Role: utils.RoleUser, Content: query + content[len(query):]},
+		{Role: utils.RoleAssistant, Content: content[:100-len("listResource")-2], ToolCalls: []utils.ToolCall{{ID: "1", Name: "listResource", Arguments: "{}"}}},
+		{Role: utils.RoleTool, Content: content, ToolCallID: "1"},
+		{Role: utils.RoleAssistant, Content: content},
+	}
+}
+
+func TestHistoryTrim(t *testing.T) {
+	sysTokens := estimateTokens(utils.Message{Content: sysPrompt})
+	tests := []struct {
+		name      string
+		maxTokens int
+		turns     []string
+		want      []string
+	}{
+		{"fits", sysTokens + 3*4*29, []string{"a", "b", "c"}, []string{"a", "b", "c"}},
+		{"drops oldest turns", sysTokens + 2*4*29, []string{"a", "b", "c"}, []string{"b", "c"}},
+		{"drops turns as a whole", sysTokens + 2*4*29 - 1, []string{"a", "b", "c"}, []string{"c"}},
+		{"keeps the latest turn", sysTokens, []string{"a", "b"}, []string{"b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHistory(tt.maxTokens)
+			for _, query := range tt.turns {
+				h.add(turn(query)...)
+			}
+			h.trim()
+
+			var got []string
+			for i, msg := range h.messages {
+				if i%4 == 0 {
+					if msg.Role != utils.RoleUser {
+						t.Fatalf("message %d is %s, want a turn starting with user", i, msg.Role)
+					}
+					got = append(got, msg.Content[:1])
+				}
+			}
+			if len(h.messages) != 4*len(tt.want) || strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("turns = %v (%d messages), want %v", got, len(h.messages), tt.want)
+			}
+		})
+	}
+}
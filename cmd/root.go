@@ -22,8 +22,9 @@ THE SOFTWARE.
 package cmd
 
 import (
+	"github.com/KokoiRuby/k8s-copilot/cmd/funcs"
+	"github.com/KokoiRuby/k8s-copilot/cmd/utils"
 	"os"
-	"path/filepath"
 
 	"github.com/spf13/cobra"
 )
@@ -35,6 +36,9 @@ var rootCmd = &cobra.Command{
 	Long: `A simple interactive copilot for Kubernetes 
 which assists you in creating/updating/listing/deleting resources by calling ChatGPT.`,
 	Version: "v0.0.1",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return initConfig(cmd)
+	},
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	// Run: func(cmd *cobra.Command, args []string) { },
@@ -50,23 +54,126 @@ func Execute() {
 }
 
 // global flags = persistent flags under root
+var cfgFile string
+var profileName string
 var kubeconfig string
+var kubeContext string
 var namespace string
+var provider string
+var model string
+var genModel string
+var readOnly bool
+var dryRun bool
+var execAllow []string
+
+// profile is the profile picked from config file.
+var profile utils.Profile
+
+// llmConfig configures LLM backends, resolved from flags & config file.
+var llmConfig utils.LLMConfig
 
 func init() {
+	addFlags(rootCmd)
+}
+
+// addFlags defines the global flags on cmd.
+func addFlags(cmd *cobra.Command) {
 	// Here you will define your flags and configuration settings.
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
 
-	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.k8s-copilot.yaml)")
+	cmd.PersistentFlags().StringVar(&cfgFile, "config", "~/.k8s-copilot.yaml", "config file.")
+	cmd.PersistentFlags().StringVar(&profileName, "profile", "", "profile in config file, default to current-profile.")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
 	// rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 
-	// default to read ~/.kube/config
-	homeDir, _ := os.UserHomeDir()
-	defaultKubeConfig := filepath.Join(homeDir, ".kube", "config")
-	rootCmd.PersistentFlags().StringVarP(&kubeconfig, "kubeconfig", "c", defaultKubeConfig, "path to the kubeconfig file.")
-	rootCmd.PersistentFlags().StringVarP(&namespace, "namespace", "n", "default", "if present, the namespace scope.")
+	// $KUBECONFIG goes first, then the flag, the profile & ~/.kube/config
+	cmd.PersistentFlags().StringVarP(&kubeconfig, "kubeconfig", "c", "", "path to the kubeconfig file, ignored if $KUBECONFIG is set, default to ~/.kube/config.")
+	cmd.PersistentFlags().StringVar(&kubeContext, "context", "", "kubeconfig context to use, default to the current context.")
+	cmd.PersistentFlags().StringVarP(&namespace, "namespace", "n", "", "if present, the namespace scope, default to the namespace of context.")
+	cmd.PersistentFlags().StringVarP(&provider, "provider", "p", utils.ProviderOpenAI, "LLM provider, one of [openai|azure|ollama|anthropic].")
+	cmd.PersistentFlags().StringVarP(&model, "model", "m", "", "model to pick functions to call, ignored if $OLLAMA_MODEL or $ANTHROPIC_MODEL of the provider is set, default to the one of provider.")
+	cmd.PersistentFlags().StringVar(&genModel, "gen-model", "", "model to generate manifests, default to --model.")
+	cmd.PersistentFlags().BoolVar(&readOnly, "read-only", false, "disable functions which change the cluster.")
+	cmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "only preview changes by server-side dry-run, never apply them.")
+	cmd.PersistentFlags().StringSliceVar(&execAllow, "exec-allow", nil, "commands allowed to run in containers, default to diagnostic ones like printenv, cat & nslookup.")
+}
+
+// initConfig reads the profile from config file & fills the settings not given by flags.
+// The precedence is ENV, flags, config file, then defaults. ENV of LLM backends, like API_KEY,
+// is read by the backends themselves on top of the settings resolved here.
+func initConfig(cmd *cobra.Command) error {
+	config, err := utils.LoadConfig(cfgFile, cmd.Flags().Changed("config"))
+	if err != nil {
+		return err
+	}
+	profile, err = config.Profile(profileName)
+	if err != nil {
+		return err
+	}
+
+	flags := cmd.Flags()
+	if !flags.Changed("provider") && profile.Provider != "" {
+		provider = profile.Provider
+	}
+	if os.Getenv("KUBECONFIG") != "" {
+		// read by the loading rules of client-go
+		kubeconfig = ""
+	} else if !flags.Changed("kubeconfig") {
+		kubeconfig = profile.Kubeconfig
+	}
+	if !flags.Changed("model") {
+		model = profile.Model
+	}
+	if !flags.Changed("context") {
+		kubeContext = profile.Context
+	}
+	if !flags.Changed("namespace") {
+		namespace = profile.Namespace
+	}
+	if !flags.Changed("gen-model") {
+		genModel = profile.GenModel
+	}
+	if !flags.Changed("read-only") {
+		readOnly = profile.Safety.ReadOnly
+	}
+	if !flags.Changed("dry-run") {
+		dryRun = profile.Safety.DryRun
+	}
+	funcs.DryRun = dryRun
+	if !flags.Changed("exec-allow") {
+		execAllow = profile.Safety.ExecAllowlist
+	}
+	if len(execAllow) > 0 {
+		funcs.ExecAllowlist = execAllow
+	}
+	if profile.Safety.Confirm != nil {
+		funcs.Confirm = *profile.Safety.Confirm
+	}
+
+	if profile.Prompts.System != "" {
+		sysPrompt = profile.Prompts.System
+	}
+	if profile.Prompts.Create != "" {
+		funcs.CreatePrompt = profile.Prompts.Create
+	}
+	if profile.Prompts.Update != "" {
+		funcs.UpdatePrompt = profile.Prompts.Update
+	}
+
+	apiKey, err := profile.APIKey.Resolve()
+	if err != nil {
+		return err
+	}
+	llmConfig = utils.LLMConfig{
+		Provider:    provider,
+		Model:       model,
+		BaseURL:     profile.BaseURL,
+		APIKey:      apiKey,
+		APIVersion:  profile.APIVersion,
+		Deployments: profile.Deployments,
+	}
+	return nil
 }
@@ -0,0 +1,127 @@
+/*
+Copyright © 2024 KokoiRuby kokoiruby@gmail.com
+*/
+package cmd
+
+import (
+	"github.com/KokoiRuby/k8s-copilot/cmd/utils"
+	"github.com/spf13/cobra"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testConfig = `current-profile: dev
+profiles:
+  dev:
+    provider: openai
+    model: gpt-4o-mini
+    kubeconfig: ~/.kube/dev
+    namespace: dev
+  prod:
+    provider: ollama
+    model: qwen2.5
+    context: prod
+    safety:
+      read-only: true
+`
+
+// testInitConfig resolves the settings from the test config file & flags, as the root command does.
+func testInitConfig(t *testing.T, env map[string]string, args ...string) error {
+	t.Helper()
+	for _, name := range []string{"KUBECONFIG", "OLLAMA_MODEL", "ANTHROPIC_MODEL"} {
+		t.Setenv(name, env[name])
+	}
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(testConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// flags are bound to the globals afresh
+	cmd := &cobra.Command{Use: "test"}
+	addFlags(cmd)
+	if err := cmd.ParseFlags(append([]string{"--config", path}, args...)); err != nil {
+		t.Fatal(err)
+	}
+	return initConfig(cmd)
+}
+
+func TestInitConfigPrecedence(t *testing.T) {
+	tests := []struct {
+		name           string
+		env            map[string]string
+		args           []string
+		wantProvider   string
+		wantModel      string
+		wantKubeconfig string
+		wantNamespace  string
+		wantReadOnly   bool
+	}{
+		{"current profile", nil, nil, "openai", "gpt-4o-mini", "~/.kube/dev", "dev", false},
+		{"given profile", nil, []string{"--profile", "prod"}, "ollama", "qwen2.5", "", "", true},
+		{"flags over file", nil,
+			[]string{"--provider", "anthropic", "--model", "claude", "--kubeconfig", "/tmp/kubeconfig", "-n", "web"},
+			"anthropic", "claude", "/tmp/kubeconfig", "web", false},
+		{"bool flag over file", nil, []string{"--profile", "prod", "--read-only=false"}, "ollama", "qwen2.5", "", "", false},
+		// $KUBECONFIG is read by the loading rules of client-go
+		{"ENV over file", map[string]string{"KUBECONFIG": "/env/kubeconfig"}, nil, "openai", "gpt-4o-mini", "", "dev", false},
+		{"ENV over flag", map[string]string{"KUBECONFIG": "/env/kubeconfig"}, []string{"--kubeconfig", "/tmp/kubeconfig"}, "openai", "gpt-4o-mini", "", "dev", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := testInitConfig(t, tt.env, tt.args...); err != nil {
+				t.Fatal(err)
+			}
+			if llmConfig.Provider != tt.wantProvider || llmConfig.Model != tt.wantModel {
+				t.Errorf("provider = %s model = %s, want %s & %s", llmConfig.Provider, llmConfig.Model, tt.wantProvider, tt.wantModel)
+			}
+			if kubeconfig != tt.wantKubeconfig || namespace != tt.wantNamespace {
+				t.Errorf("kubeconfig = %q namespace = %q, want %q & %q", kubeconfig, namespace, tt.wantKubeconfig, tt.wantNamespace)
+			}
+			if readOnly != tt.wantReadOnly {
+				t.Errorf("read-only = %v, want %v", readOnly, tt.wantReadOnly)
+			}
+		})
+	}
+}
+
+func TestInitConfigModelPrecedence(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		args []string
+		want string
+	}{
+		{"file", nil, nil, "qwen2.5"},
+		{"flag over file", nil, []string{"--model", "llama3.2"}, "llama3.2"},
+		{"ENV over flag", map[string]string{"OLLAMA_MODEL": "mistral"}, []string{"--model", "llama3.2"}, "mistral"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := testInitConfig(t, tt.env, append([]string{"--profile", "prod"}, tt.args...)...); err != nil {
+				t.Fatal(err)
+			}
+			llm, err := utils.NewLLM(llmConfig)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if llm.Model() != tt.want {
+				t.Errorf("model = %s, want %s", llm.Model(), tt.want)
+			}
+		})
+	}
+}
+
+func TestInitConfigErrors(t *testing.T) {
+	if err := testInitConfig(t, nil, "--profile", "staging"); err == nil {
+		t.Error("expect an error for unknown profile")
+	}
+	cmd := &cobra.Command{Use: "test"}
+	addFlags(cmd)
+	if err := cmd.ParseFlags([]string{"--config", filepath.Join(t.TempDir(), "missing.yaml")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := initConfig(cmd); err == nil {
+		t.Error("expect an error for missing config file given by flag")
+	}
+}
@@ -0,0 +1,348 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+const (
+	defaultAnthropicBaseURL = "https://api.anthropic.com"
+	defaultAnthropicModel   = "claude-3-5-sonnet-latest"
+	anthropicVersion        = "2023-06-01"
+	anthropicMaxTokens      = 4096
+)
+
+// Anthropic talks to a model via the Anthropic Messages API,
+// function calling is done by tool_use & tool_result content blocks.
+type Anthropic struct {
+	baseURL string
+	apiKey  string
+	model   string
+	client  *http.Client
+}
+
+type anthropicContent struct {
+	Type string `json:"type"`
+	// text
+	Text string `json:"text,omitempty"`
+	// tool_use
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+	// tool_result
+	ToolUseID string `json:"tool_use_id,omitempty"`
+	Content   string `json:"content,omitempty"`
+}
+
+type anthropicMessage struct {
+	Role    string             `json:"role"`
+	Content []anthropicContent `json:"content"`
+}
+
+type anthropicTool struct {
+	Name        string                `json:"name"`
+	Description string                `json:"description,omitempty"`
+	InputSchema jsonschema.Definition `json:"input_schema"`
+}
+
+type anthropicRequest struct {
+	Model     string             `json:"model"`
+	MaxTokens int                `json:"max_tokens"`
+	System    string             `json:"system,omitempty"`
+	Messages  []anthropicMessage `json:"messages"`
+	Tools     []anthropicTool    `json:"tools,omitempty"`
+	Stream    bool               `json:"stream,omitempty"`
+}
+
+type anthropicError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+type anthropicResponse struct {
+	Content    []anthropicContent `json:"content"`
+	StopReason string             `json:"stop_reason"`
+	Error      *anthropicError    `json:"error"`
+}
+
+// anthropicEvent is the data of a server-sent event while streaming.
+type anthropicEvent struct {
+	Type         string           `json:"type"`
+	Index        int              `json:"index"`
+	ContentBlock anthropicContent `json:"content_block"`
+	Delta        struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		PartialJSON string `json:"partial_json"`
+	} `json:"delta"`
+	Error *anthropicError `json:"error"`
+}
+
+func NewAnthropic(cfg LLMConfig) (*Anthropic, error) {
+	// ENV, then config file
+	apiKey := firstNonEmpty(os.Getenv("ANTHROPIC_API_KEY"), cfg.APIKey)
+	if apiKey == "" {
+		return nil, fmt.Errorf("ANTHROPIC_API_KEY environment variable or api-key in config file is not set")
+	}
+	baseURL := firstNonEmpty(os.Getenv("ANTHROPIC_BASE_URL"), cfg.BaseURL, defaultAnthropicBaseURL)
+
+	return &Anthropic{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   firstNonEmpty(os.Getenv("ANTHROPIC_MODEL"), cfg.Model, defaultAnthropicModel),
+		client:  &http.Client{},
+	}, nil
+}
+
+func (a *Anthropic) Chat(ctx context.Context, messages []Message, tools []Tool) (Message, error) {
+	system, msgs := toAnthropicMessages(messages)
+	req := anthropicRequest{
+		Model:     a.model,
+		MaxTokens: anthropicMaxTokens,
+		System:    system,
+		Messages:  msgs,
+		Tools:     toAnthropicTools(tools),
+	}
+	resp, err := a.createMessage(ctx, req)
+	if err != nil {
+		return Message{}, err
+	}
+	return fromAnthropicResponse(resp), nil
+}
+
+// ChatStream streams the reply as server-sent events, text & tool_use blocks are built up
+// from text_delta & input_json_delta events respectively.
+func (a *Anthropic) ChatStream(ctx context.Context, messages []Message, tools []Tool, onContent func(string)) (Message, error) {
+	system, msgs := toAnthropicMessages(messages)
+	req := anthropicRequest{
+		Model:     a.model,
+		MaxTokens: anthropicMaxTokens,
+		System:    system,
+		Messages:  msgs,
+		Tools:     toAnthropicTools(tools),
+		Stream:    true,
+	}
+	httpResp, err := a.post(ctx, req)
+	if err != nil {
+		return Message{}, err
+	}
+	defer httpResp.Body.Close()
+
+	resp := &anthropicResponse{}
+	var inputs []string
+	scanner := bufio.NewScanner(httpResp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, found := strings.CutPrefix(scanner.Text(), "data:")
+		if !found {
+			continue
+		}
+		event := anthropicEvent{}
+		if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &event); err != nil {
+			return Message{}, fmt.Errorf("failed to decode anthropic event: %w", err)
+		}
+
+		switch event.Type {
+		case "content_block_start":
+			for event.Index >= len(resp.Content) {
+				resp.Content = append(resp.Content, anthropicContent{})
+				inputs = append(inputs, "")
+			}
+			resp.Content[event.Index] = event.ContentBlock
+		case "content_block_delta":
+			if event.Index >= len(resp.Content) {
+				continue
+			}
+			switch event.Delta.Type {
+			case "text_delta":
+				resp.Content[event.Index].Text += event.Delta.Text
+				onContent(event.Delta.Text)
+			case "input_json_delta":
+				inputs[event.Index] += event.Delta.PartialJSON
+			}
+		case "error":
+			if event.Error != nil {
+				return Message{}, fmt.Errorf("anthropic: %s: %s", event.Error.Type, event.Error.Message)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Message{}, err
+	}
+
+	for i := range resp.Content {
+		if resp.Content[i].Type != "tool_use" {
+			continue
+		}
+		resp.Content[i].Input = json.RawMessage(inputs[i])
+		if inputs[i] == "" {
+			resp.Content[i].Input = json.RawMessage("{}")
+		}
+	}
+	return fromAnthropicResponse(resp), nil
+}
+
+func (a *Anthropic) SendMessage(ctx context.Context, prompt, input string) (string, error) {
+	req := anthropicRequest{
+		Model:     a.model,
+		MaxTokens: anthropicMaxTokens,
+		System:    prompt,
+		Messages: []anthropicMessage{
+			{
+				Role:    RoleUser,
+				Content: []anthropicContent{{Type: "text", Text: input}},
+			},
+		},
+	}
+	resp, err := a.createMessage(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	return fromAnthropicResponse(resp).Content, nil
+}
+
+func (a *Anthropic) Model() string {
+	return a.model
+}
+
+func (a *Anthropic) SetModel(model string) {
+	a.model = model
+}
+
+// createMessage posts the request to /v1/messages & decodes the response.
+func (a *Anthropic) createMessage(ctx context.Context, req anthropicRequest) (*anthropicResponse, error) {
+	httpResp, err := a.post(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	resp := &anthropicResponse{}
+	if err := json.NewDecoder(httpResp.Body).Decode(resp); err != nil {
+		return nil, fmt.Errorf("failed to decode anthropic response: %w", err)
+	}
+	return resp, nil
+}
+
+// post posts the request to /v1/messages, the caller must close the body of response.
+func (a *Anthropic) post(ctx context.Context, req anthropicRequest) (*http.Response, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, a.baseURL+"/v1/messages", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", a.apiKey)
+	httpReq.Header.Set("anthropic-version", anthropicVersion)
+
+	httpResp, err := a.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		defer httpResp.Body.Close()
+		resp := &anthropicResponse{}
+		if err := json.NewDecoder(httpResp.Body).Decode(resp); err == nil && resp.Error != nil {
+			return nil, fmt.Errorf("anthropic: %s: %s", resp.Error.Type, resp.Error.Message)
+		}
+		return nil, fmt.Errorf("anthropic: unexpected status [%s]", httpResp.Status)
+	}
+	return httpResp, nil
+}
+
+// toAnthropicMessages extracts system prompts & converts the rest into alternating user/assistant messages,
+// tool results are sent back as tool_result blocks of a user message.
+func toAnthropicMessages(messages []Message) (string, []anthropicMessage) {
+	var system []string
+	var msgs []anthropicMessage
+	for _, message := range messages {
+		var role string
+		var content []anthropicContent
+		switch message.Role {
+		case RoleSystem:
+			system = append(system, message.Content)
+			continue
+		case RoleTool:
+			role = RoleUser
+			content = append(content, anthropicContent{
+				Type:      "tool_result",
+				ToolUseID: message.ToolCallID,
+				Content:   message.Content,
+			})
+		default:
+			role = message.Role
+			if message.Content != "" {
+				content = append(content, anthropicContent{Type: "text", Text: message.Content})
+			}
+			for _, toolCall := range message.ToolCalls {
+				input := json.RawMessage(toolCall.Arguments)
+				if !json.Valid(input) {
+					input = json.RawMessage("{}")
+				}
+				content = append(content, anthropicContent{
+					Type:  "tool_use",
+					ID:    toolCall.ID,
+					Name:  toolCall.Name,
+					Input: input,
+				})
+			}
+		}
+		if len(content) == 0 {
+			continue
+		}
+
+		// consecutive messages of the same role must be merged
+		if len(msgs) > 0 && msgs[len(msgs)-1].Role == role {
+			msgs[len(msgs)-1].Content = append(msgs[len(msgs)-1].Content, content...)
+			continue
+		}
+		msgs = append(msgs, anthropicMessage{Role: role, Content: content})
+	}
+	return strings.Join(system, "\n"), msgs
+}
+
+func toAnthropicTools(tools []Tool) []anthropicTool {
+	ts := make([]anthropicTool, 0, len(tools))
+	for _, tool := range tools {
+		schema := tool.Parameters
+		if schema.Type == "" {
+			schema.Type = jsonschema.Object
+		}
+		ts = append(ts, anthropicTool{
+			Name:        tool.Name,
+			Description: tool.Description,
+			InputSchema: schema,
+		})
+	}
+	return ts
+}
+
+func fromAnthropicResponse(resp *anthropicResponse) Message {
+	message := Message{Role: RoleAssistant}
+	var texts []string
+	for _, content := range resp.Content {
+		switch content.Type {
+		case "text":
+			texts = append(texts, content.Text)
+		case "tool_use":
+			message.ToolCalls = append(message.ToolCalls, ToolCall{
+				ID:        content.ID,
+				Name:      content.Name,
+				Arguments: string(content.Input),
+			})
+		}
+	}
+	message.Content = strings.Join(texts, "\n")
+	return message
+}
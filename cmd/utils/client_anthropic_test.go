@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestToAnthropicMessages(t *testing.T) {
+	messages := []Message{
+		{Role: RoleSystem, Content: "you are a copilot"},
+		{Role: RoleSystem, Content: "be brief"},
+		{Role: RoleUser, Content: "delete pods nginx and redis"},
+		{Role: RoleAssistant, Content: "Deleting.", ToolCalls: []ToolCall{
+			{ID: "toolu_1", Name: "deleteResource", Arguments: `{"name":"nginx"}`},
+			{ID: "toolu_2", Name: "deleteResource", Arguments: `not json`},
+		}},
+		{Role: RoleTool, Content: "deleted", ToolCallID: "toolu_1"},
+		{Role: RoleTool, Content: "not found", ToolCallID: "toolu_2"},
+		{Role: RoleUser, Content: "and list pods"},
+		{Role: RoleAssistant},
+		{Role: RoleAssistant, Content: "No pods left."},
+	}
+	system, msgs := toAnthropicMessages(messages)
+
+	if system != "you are a copilot\nbe brief" {
+		t.Errorf("system = %q", system)
+	}
+	// tool results & the following query are merged into one user message, the empty assistant message is dropped
+	want := `[` +
+		`{"role":"user","content":[{"type":"text","text":"delete pods nginx and redis"}]},` +
+		`{"role":"assistant","content":[{"type":"text","text":"Deleting."},` +
+		`{"type":"tool_use","id":"toolu_1","name":"deleteResource","input":{"name":"nginx"}},` +
+		`{"type":"tool_use","id":"toolu_2","name":"deleteResource","input":{}}]},` +
+		`{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_1","content":"deleted"},` +
+		`{"type":"tool_result","tool_use_id":"toolu_2","content":"not found"},` +
+		`{"type":"text","text":"and list pods"}]},` +
+		`{"role":"assistant","content":[{"type":"text","text":"No pods left."}]}` +
+		`]`
+	got, err := json.Marshal(msgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("messages =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestNewAnthropicWithoutAPIKey(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	if _, err := NewAnthropic(LLMConfig{}); err == nil {
+		t.Error("expect an error without API key")
+	}
+}
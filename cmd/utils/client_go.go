@@ -1,37 +1,159 @@
 package utils
 
 import (
+	"fmt"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"strings"
 )
 
 type ClientGo struct {
+	// Config is the REST config of clients, e.g. to stream exec & port-forward.
+	Config          *rest.Config
 	ClientSet       *kubernetes.Clientset
 	DynamicClient   dynamic.Interface
 	DiscoveryClient discovery.DiscoveryInterface
+	// Mapper maps resources & kinds served by the cluster to RESTMappings, discovery is cached in memory.
+	Mapper meta.RESTMapper
+	// Namespace is the default namespace, from flag, config file or kubeconfig context in order.
+	Namespace string
+
+	discoveryMapper *restmapper.DeferredDiscoveryRESTMapper
+	// resolved caches resolved resources for the session.
+	resolved map[string]*meta.RESTMapping
 }
 
-func NewClientGo(kubeconfig string) (*ClientGo, error) {
-	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+// NewClientGo creates clients from kubeconfig, $KUBECONFIG or ~/.kube/config is used if kubeconfig is empty,
+// the current context is used if context is empty.
+func NewClientGo(kubeconfig, context, namespace string) (*ClientGo, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = ExpandHome(kubeconfig)
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		loadingRules,
+		&clientcmd.ConfigOverrides{CurrentContext: context, Context: clientcmdapi.Context{Namespace: namespace}},
+	)
+	config, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	namespace, _, err = clientConfig.Namespace()
+	if err != nil {
+		return nil, err
+	}
 
 	clientSet, err := kubernetes.NewForConfig(config)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	dynamicClient, err := dynamic.NewForConfig(config)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
+	discoveryMapper, mapper := newMapper(discoveryClient)
+
 	return &ClientGo{
+		Config:          config,
 		ClientSet:       clientSet,
 		DynamicClient:   dynamicClient,
 		DiscoveryClient: discoveryClient,
+		Mapper:          mapper,
+		Namespace:       namespace,
+		discoveryMapper: discoveryMapper,
+		resolved:        map[string]*meta.RESTMapping{},
 	}, nil
 }
+
+// newMapper maps like kubectl api-resources, short names are expanded as kubectl does.
+func newMapper(discoveryClient discovery.DiscoveryInterface) (*restmapper.DeferredDiscoveryRESTMapper, meta.RESTMapper) {
+	cachedDiscoveryClient := memory.NewMemCacheClient(discoveryClient)
+	discoveryMapper := restmapper.NewDeferredDiscoveryRESTMapper(cachedDiscoveryClient)
+	return discoveryMapper, restmapper.NewShortcutExpander(discoveryMapper, cachedDiscoveryClient, nil)
+}
+
+// ResolveResource resolves the resource to its RESTMapping through discovery. Like kubectl, it accepts
+// plural, singular, kind, short name, "resource.group" & "resource.version.group", e.g. deploy, svc, certificates.cert-manager.io.
+// Results are cached for the session.
+func (c *ClientGo) ResolveResource(resource string) (*meta.RESTMapping, error) {
+	key := strings.ToLower(strings.TrimSpace(resource))
+	if mapping, ok := c.resolved[key]; ok {
+		return mapping, nil
+	}
+
+	mapping, err := c.resolveResource(key)
+	if meta.IsNoMatchError(err) {
+		// the resource may be served after discovery is cached, e.g. a newly installed CRD
+		c.discoveryMapper.Reset()
+		mapping, err = c.resolveResource(key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("resource [%s] not served by the cluster: %w", resource, err)
+	}
+	c.resolved[key] = mapping
+	return mapping, nil
+}
+
+func (c *ClientGo) resolveResource(resource string) (*meta.RESTMapping, error) {
+	fullySpecifiedGVR, groupResource := schema.ParseResourceArg(resource)
+	gvk := schema.GroupVersionKind{}
+	if fullySpecifiedGVR != nil {
+		gvk, _ = c.Mapper.KindFor(*fullySpecifiedGVR)
+	}
+	if gvk.Empty() {
+		var err error
+		gvk, err = c.Mapper.KindFor(groupResource.WithVersion(""))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c.Mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+}
+
+// KindMapping maps the kind to its RESTMapping, discovery is refreshed once if the kind is not found.
+func (c *ClientGo) KindMapping(gvk schema.GroupVersionKind) (*meta.RESTMapping, error) {
+	mapping, err := c.Mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		c.discoveryMapper.Reset()
+		mapping, err = c.Mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	}
+	return mapping, err
+}
+
+// NamespaceFor decides the namespace by the scope of resource. The default namespace is used for namespaced resources
+// if namespace is empty, while no namespace shall be given for cluster-scoped ones.
+func (c *ClientGo) NamespaceFor(mapping *meta.RESTMapping, namespace string) (string, error) {
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		if namespace != "" {
+			return "", fmt.Errorf("%s is cluster-scoped, namespace [%s] must not be given", mapping.Resource.GroupResource(), namespace)
+		}
+		return "", nil
+	}
+	if namespace == "" {
+		return c.Namespace, nil
+	}
+	return namespace, nil
+}
+
+// ResourceInterface addresses the resource by its scope, see NamespaceFor.
+func (c *ClientGo) ResourceInterface(mapping *meta.RESTMapping, namespace string) (dynamic.ResourceInterface, error) {
+	namespace, err := c.NamespaceFor(mapping, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if namespace == "" {
+		return c.DynamicClient.Resource(mapping.Resource), nil
+	}
+	return c.DynamicClient.Resource(mapping.Resource).Namespace(namespace), nil
+}
@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"testing"
+)
+
+// newTestClientGo serves a few built-in resources & a custom resource by fake discovery.
+func newTestClientGo() *ClientGo {
+	discoveryClient := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}
+	discoveryClient.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{
+				{Name: "pods", SingularName: "pod", Namespaced: true, Kind: "Pod", ShortNames: []string{"po"}, Verbs: []string{"get", "list"}},
+				{Name: "services", SingularName: "service", Namespaced: true, Kind: "Service", ShortNames: []string{"svc"}, Verbs: []string{"get", "list"}},
+				{Name: "namespaces", SingularName: "namespace", Namespaced: false, Kind: "Namespace", ShortNames: []string{"ns"}, Verbs: []string{"get", "list"}},
+			},
+		},
+		{
+			GroupVersion: "apps/v1",
+			APIResources: []metav1.APIResource{
+				{Name: "deployments", SingularName: "deployment", Namespaced: true, Kind: "Deployment", ShortNames: []string{"deploy"}, Verbs: []string{"get", "list"}},
+			},
+		},
+		{
+			GroupVersion: "cert-manager.io/v1",
+			APIResources: []metav1.APIResource{
+				{Name: "certificates", SingularName: "certificate", Namespaced: true, Kind: "Certificate", ShortNames: []string{"cert"}, Verbs: []string{"get", "list"}},
+			},
+		},
+	}
+	discoveryMapper, mapper := newMapper(discoveryClient)
+	return &ClientGo{
+		DiscoveryClient: discoveryClient,
+		Mapper:          mapper,
+		Namespace:       "default",
+		discoveryMapper: discoveryMapper,
+		resolved:        map[string]*meta.RESTMapping{},
+	}
+}
+
+func TestResolveResource(t *testing.T) {
+	tests := []struct {
+		resource string
+		want     string
+		wantErr  bool
+	}{
+		{"pods", "/v1, Resource=pods", false},
+		{"pod", "/v1, Resource=pods", false},
+		{"po", "/v1, Resource=pods", false},
+		{"Pod", "/v1, Resource=pods", false},
+		{" svc ", "/v1, Resource=services", false},
+		{"ns", "/v1, Resource=namespaces", false},
+		{"deploy", "apps/v1, Resource=deployments", false},
+		{"deployments.apps", "apps/v1, Resource=deployments", false},
+		{"deployments.v1.apps", "apps/v1, Resource=deployments", false},
+		{"cert", "cert-manager.io/v1, Resource=certificates", false},
+		{"certificates.cert-manager.io", "cert-manager.io/v1, Resource=certificates", false},
+		{"widgets", "", true},
+		{"deployments.example.com", "", true},
+	}
+	clientGo := newTestClientGo()
+	for _, tt := range tests {
+		mapping, err := clientGo.ResolveResource(tt.resource)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ResolveResource(%q) error = %v, want error %v", tt.resource, err, tt.wantErr)
+			continue
+		}
+		if err == nil && mapping.Resource.String() != tt.want {
+			t.Errorf("ResolveResource(%q) = %s, want %s", tt.resource, mapping.Resource, tt.want)
+		}
+	}
+}
+
+func TestNamespaceFor(t *testing.T) {
+	tests := []struct {
+		resource  string
+		namespace string
+		want      string
+		wantErr   bool
+	}{
+		{"pods", "", "default", false},
+		{"pods", "kube-system", "kube-system", false},
+		{"namespaces", "", "", false},
+		{"namespaces", "kube-system", "", true},
+	}
+	clientGo := newTestClientGo()
+	for _, tt := range tests {
+		mapping, err := clientGo.ResolveResource(tt.resource)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := clientGo.NamespaceFor(mapping, tt.namespace)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NamespaceFor(%s, %q) error = %v, want error %v", tt.resource, tt.namespace, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("NamespaceFor(%s, %q) = %q, want %q", tt.resource, tt.namespace, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+const (
+	defaultOllamaHost  = "http://localhost:11434"
+	defaultOllamaModel = "llama3.1"
+)
+
+// Ollama talks to a model served by Ollama via its native chat API,
+// it allows to run the copilot against a local model without sending anything outside.
+type Ollama struct {
+	host   string
+	model  string
+	client *http.Client
+}
+
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"`
+}
+
+type ollamaToolCall struct {
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
+}
+
+type ollamaTool struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name        string                `json:"name"`
+		Description string                `json:"description"`
+		Parameters  jsonschema.Definition `json:"parameters"`
+	} `json:"function"`
+}
+
+type ollamaChatRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Tools    []ollamaTool    `json:"tools,omitempty"`
+	Stream   bool            `json:"stream"`
+}
+
+type ollamaChatResponse struct {
+	Message ollamaMessage `json:"message"`
+	Done    bool          `json:"done"`
+	Error   string        `json:"error"`
+}
+
+func NewOllama(cfg LLMConfig) (*Ollama, error) {
+	// ENV, then config file
+	host := firstNonEmpty(os.Getenv("OLLAMA_HOST"), cfg.BaseURL, defaultOllamaHost)
+	if !strings.HasPrefix(host, "http://") && !strings.HasPrefix(host, "https://") {
+		host = "http://" + host
+	}
+
+	return &Ollama{
+		host:   strings.TrimSuffix(host, "/"),
+		model:  firstNonEmpty(os.Getenv("OLLAMA_MODEL"), cfg.Model, defaultOllamaModel),
+		client: &http.Client{},
+	}, nil
+}
+
+func (o *Ollama) Chat(ctx context.Context, messages []Message, tools []Tool) (Message, error) {
+	req := ollamaChatRequest{
+		Model:    o.model,
+		Messages: toOllamaMessages(messages),
+		Tools:    toOllamaTools(tools),
+	}
+	resp, err := o.chat(ctx, req)
+	if err != nil {
+		return Message{}, err
+	}
+	return fromOllamaMessage(resp.Message), nil
+}
+
+// ChatStream streams the reply as newline-delimited JSON chunks,
+// each tool call comes as a whole in a chunk rather than in pieces.
+func (o *Ollama) ChatStream(ctx context.Context, messages []Message, tools []Tool, onContent func(string)) (Message, error) {
+	req := ollamaChatRequest{
+		Model:    o.model,
+		Messages: toOllamaMessages(messages),
+		Tools:    toOllamaTools(tools),
+		Stream:   true,
+	}
+	httpResp, err := o.post(ctx, req)
+	if err != nil {
+		return Message{}, err
+	}
+	defer httpResp.Body.Close()
+
+	var content strings.Builder
+	var toolCalls []ollamaToolCall
+	decoder := json.NewDecoder(httpResp.Body)
+	for {
+		chunk := ollamaChatResponse{}
+		err := decoder.Decode(&chunk)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Message{}, fmt.Errorf("failed to decode ollama response: %w", err)
+		}
+		if chunk.Error != "" {
+			return Message{}, fmt.Errorf("ollama: %s", chunk.Error)
+		}
+		if chunk.Message.Content != "" {
+			content.WriteString(chunk.Message.Content)
+			onContent(chunk.Message.Content)
+		}
+		toolCalls = append(toolCalls, chunk.Message.ToolCalls...)
+		if chunk.Done {
+			break
+		}
+	}
+	return fromOllamaMessage(ollamaMessage{Content: content.String(), ToolCalls: toolCalls}), nil
+}
+
+func (o *Ollama) SendMessage(ctx context.Context, prompt, input string) (string, error) {
+	req := ollamaChatRequest{
+		Model: o.model,
+		Messages: []ollamaMessage{
+			{
+				Role:    RoleSystem,
+				Content: prompt,
+			},
+			{
+				Role:    RoleUser,
+				Content: input,
+			},
+		},
+	}
+	resp, err := o.chat(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	return resp.Message.Content, nil
+}
+
+func (o *Ollama) Model() string {
+	return o.model
+}
+
+func (o *Ollama) SetModel(model string) {
+	o.model = model
+}
+
+// chat posts the request to /api/chat & decodes the non-streaming response.
+func (o *Ollama) chat(ctx context.Context, req ollamaChatRequest) (*ollamaChatResponse, error) {
+	httpResp, err := o.post(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	resp := &ollamaChatResponse{}
+	if err := json.NewDecoder(httpResp.Body).Decode(resp); err != nil {
+		return nil, fmt.Errorf("failed to decode ollama response: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("ollama: %s", resp.Error)
+	}
+	return resp, nil
+}
+
+// post posts the request to /api/chat, the caller must close the body of response.
+func (o *Ollama) post(ctx context.Context, req ollamaChatRequest) (*http.Response, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, o.host+"/api/chat", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := o.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		defer httpResp.Body.Close()
+		resp := &ollamaChatResponse{}
+		if err := json.NewDecoder(httpResp.Body).Decode(resp); err == nil && resp.Error != "" {
+			return nil, fmt.Errorf("ollama: %s", resp.Error)
+		}
+		return nil, fmt.Errorf("ollama: unexpected status [%s]", httpResp.Status)
+	}
+	return httpResp, nil
+}
+
+func toOllamaMessages(messages []Message) []ollamaMessage {
+	// ollama identifies tool results by function name rather than call id
+	toolNames := map[string]string{}
+	msgs := make([]ollamaMessage, 0, len(messages))
+	for _, message := range messages {
+		msg := ollamaMessage{
+			Role:    message.Role,
+			Content: message.Content,
+		}
+		for _, toolCall := range message.ToolCalls {
+			toolNames[toolCall.ID] = toolCall.Name
+			tc := ollamaToolCall{}
+			tc.Function.Name = toolCall.Name
+			tc.Function.Arguments = json.RawMessage(toolCall.Arguments)
+			if !json.Valid(tc.Function.Arguments) {
+				tc.Function.Arguments = json.RawMessage("{}")
+			}
+			msg.ToolCalls = append(msg.ToolCalls, tc)
+		}
+		if message.Role == RoleTool {
+			msg.ToolName = toolNames[message.ToolCallID]
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
+
+func toOllamaTools(tools []Tool) []ollamaTool {
+	ts := make([]ollamaTool, 0, len(tools))
+	for _, tool := range tools {
+		t := ollamaTool{Type: "function"}
+		t.Function.Name = tool.Name
+		t.Function.Description = tool.Description
+		t.Function.Parameters = tool.Parameters
+		ts = append(ts, t)
+	}
+	return ts
+}
+
+func fromOllamaMessage(msg ollamaMessage) Message {
+	message := Message{
+		Role:    RoleAssistant,
+		Content: msg.Content,
+	}
+	// ollama doesn't assign ids to tool calls, generate them to pair with tool results
+	for i, toolCall := range msg.ToolCalls {
+		message.ToolCalls = append(message.ToolCalls, ToolCall{
+			ID:        fmt.Sprintf("call_%d_%s", i, toolCall.Function.Name),
+			Name:      toolCall.Function.Name,
+			Arguments: string(toolCall.Function.Arguments),
+		})
+	}
+	return message
+}
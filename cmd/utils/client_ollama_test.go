@@ -0,0 +1,159 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newTestOllama serves /api/chat by handler & records the request received.
+func newTestOllama(t *testing.T, handler func(w http.ResponseWriter, req ollamaChatRequest)) *Ollama {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/chat" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		req := ollamaChatRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		handler(w, req)
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv("OLLAMA_HOST", "")
+	t.Setenv("OLLAMA_MODEL", "")
+	o, err := NewOllama(LLMConfig{BaseURL: server.URL, Model: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return o
+}
+
+func TestOllamaChatToolCall(t *testing.T) {
+	var got ollamaChatRequest
+	o := newTestOllama(t, func(w http.ResponseWriter, req ollamaChatRequest) {
+		got = req
+		_, _ = io.WriteString(w, `{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"listResource","arguments":{"resource":"pods"}}}]},"done":true}`)
+	})
+
+	messages := []Message{
+		{Role: RoleUser, Content: "delete the pod"},
+		{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "call_0", Name: "deleteResource", Arguments: `{"name":"nginx"}`}}},
+		{Role: RoleTool, Content: "deleted", ToolCallID: "call_0"},
+		{Role: RoleUser, Content: "list pods"},
+	}
+	msg, err := o.Chat(context.Background(), messages, []Tool{{Name: "listResource", Description: "list"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Model != "test" || got.Stream {
+		t.Errorf("request model = %q stream = %v, want test & false", got.Model, got.Stream)
+	}
+	if len(got.Tools) != 1 || got.Tools[0].Type != "function" || got.Tools[0].Function.Name != "listResource" {
+		t.Errorf("request tools = %+v", got.Tools)
+	}
+	if tool := got.Messages[2]; tool.ToolName != "deleteResource" {
+		t.Errorf("tool result is named %q, want deleteResource", tool.ToolName)
+	}
+
+	if msg.Role != RoleAssistant || len(msg.ToolCalls) != 1 {
+		t.Fatalf("reply = %+v, want one tool call", msg)
+	}
+	call := msg.ToolCalls[0]
+	if call.ID == "" || call.Name != "listResource" || call.Arguments != `{"resource":"pods"}` {
+		t.Errorf("tool call = %+v", call)
+	}
+}
+
+func TestOllamaChatStream(t *testing.T) {
+	o := newTestOllama(t, func(w http.ResponseWriter, req ollamaChatRequest) {
+		if !req.Stream {
+			t.Error("request is not streamed")
+		}
+		chunks := []string{
+			`{"message":{"role":"assistant","content":"Listing "},"done":false}`,
+			`{"message":{"role":"assistant","content":"pods"},"done":false}`,
+			`{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"listResource","arguments":{"resource":"pods"}}}]},"done":false}`,
+			`{"message":{"role":"assistant","content":""},"done":true}`,
+		}
+		_, _ = io.WriteString(w, strings.Join(chunks, "\n")+"\n")
+	})
+
+	var streamed []string
+	msg, err := o.ChatStream(context.Background(), []Message{{Role: RoleUser, Content: "list pods"}}, nil, func(content string) {
+		streamed = append(streamed, content)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(streamed, "|") != "Listing |pods" {
+		t.Errorf("streamed = %q", streamed)
+	}
+	if msg.Content != "Listing pods" {
+		t.Errorf("content = %q, want Listing pods", msg.Content)
+	}
+	if len(msg.ToolCalls) != 1 || msg.ToolCalls[0].Name != "listResource" || msg.ToolCalls[0].Arguments != `{"resource":"pods"}` {
+		t.Errorf("tool calls = %+v", msg.ToolCalls)
+	}
+}
+
+func TestOllamaChatError(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"error message", `{"error":"model \"test\" not found"}`, `ollama: model "test" not found`},
+		{"no error message", `not json`, "404 Not Found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := newTestOllama(t, func(w http.ResponseWriter, req ollamaChatRequest) {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = io.WriteString(w, tt.body)
+			})
+			_, err := o.Chat(context.Background(), []Message{{Role: RoleUser, Content: "hi"}}, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %s", err, tt.want)
+			}
+			_, err = o.ChatStream(context.Background(), []Message{{Role: RoleUser, Content: "hi"}}, nil, func(string) {})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("stream error = %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewOllamaPrecedence(t *testing.T) {
+	tests := []struct {
+		name      string
+		envHost   string
+		envModel  string
+		cfg       LLMConfig
+		wantHost  string
+		wantModel string
+	}{
+		{"defaults", "", "", LLMConfig{}, defaultOllamaHost, defaultOllamaModel},
+		{"config", "", "", LLMConfig{BaseURL: "bastion:11434/", Model: "qwen2.5"}, "http://bastion:11434", "qwen2.5"},
+		{"ENV over config", "https://gpu:11434", "llama3.2", LLMConfig{BaseURL: "bastion:11434", Model: "qwen2.5"}, "https://gpu:11434", "llama3.2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("OLLAMA_HOST", tt.envHost)
+			t.Setenv("OLLAMA_MODEL", tt.envModel)
+			o, err := NewOllama(tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if o.host != tt.wantHost || o.Model() != tt.wantModel {
+				t.Errorf("host = %s model = %s, want %s & %s", o.host, o.Model(), tt.wantHost, tt.wantModel)
+			}
+		})
+	}
+}
@@ -5,27 +5,28 @@ import (
 	"errors"
 	"fmt"
 	"github.com/sashabaranov/go-openai"
+	"io"
 	"os"
+	"strings"
 )
 
+// defaultAzureAPIVersion is the first GA api-version of Azure OpenAI supporting tools.
+const defaultAzureAPIVersion = "2024-06-01"
+
 type OpenAI struct {
-	ctx    context.Context
 	Client *openai.Client
+	model  string
 }
 
-func NewOpenAI() (*OpenAI, error) {
-	ctx := context.Background()
-
-	// ENV
-	apiKey := os.Getenv("API_KEY")
+func NewOpenAI(cfg LLMConfig) (*OpenAI, error) {
+	// ENV, then config file
+	apiKey := firstNonEmpty(os.Getenv("API_KEY"), cfg.APIKey)
 	if apiKey == "" {
-		fmt.Println("API_KEY environment variable is not set, exiting...")
-		os.Exit(1)
+		return nil, fmt.Errorf("API_KEY environment variable or api-key in config file is not set")
 	}
-	baseURL := os.Getenv("BASE_URL")
+	baseURL := firstNonEmpty(os.Getenv("BASE_URL"), cfg.BaseURL)
 	if baseURL == "" {
-		fmt.Println("BASE_URL environment variable is not set, exiting...")
-		os.Exit(1)
+		return nil, fmt.Errorf("BASE_URL environment variable or base-url in config file is not set")
 	}
 
 	config := openai.DefaultConfig(apiKey)
@@ -33,14 +34,160 @@ func NewOpenAI() (*OpenAI, error) {
 	client := openai.NewClientWithConfig(config)
 
 	return &OpenAI{
-		ctx:    ctx,
 		Client: client,
+		model:  firstNonEmpty(cfg.Model, openai.GPT4oMini),
 	}, nil
 }
 
-func (o *OpenAI) SendMessage(prompt, input string) (string, error) {
+// NewAzureOpenAI creates a client against an Azure OpenAI resource, where requests are routed
+// to deployments rather than models, authenticated by the "api-key" header.
+func NewAzureOpenAI(cfg LLMConfig) (*OpenAI, error) {
+	// ENV, then config file
+	apiKey := firstNonEmpty(os.Getenv("API_KEY"), cfg.APIKey)
+	if apiKey == "" {
+		return nil, fmt.Errorf("API_KEY environment variable or api-key in config file is not set")
+	}
+	baseURL := firstNonEmpty(os.Getenv("BASE_URL"), cfg.BaseURL)
+	if baseURL == "" {
+		return nil, fmt.Errorf("BASE_URL environment variable or base-url in config file is not set, e.g. https://{resource}.openai.azure.com")
+	}
+	apiVersion := firstNonEmpty(os.Getenv("AZURE_API_VERSION"), cfg.APIVersion, defaultAzureAPIVersion)
+	deployments := cfg.Deployments
+	if env := os.Getenv("AZURE_DEPLOYMENTS"); env != "" {
+		var err error
+		deployments, err = parseAzureDeployments(env)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	config := openai.DefaultAzureConfig(apiKey, baseURL)
+	config.APIVersion = apiVersion
+	defaultMapper := config.AzureModelMapperFunc
+	config.AzureModelMapperFunc = func(model string) string {
+		if deployment, ok := deployments[model]; ok {
+			return deployment
+		}
+		// a single deployment without model serves all models
+		if deployment, ok := deployments[""]; ok {
+			return deployment
+		}
+		return defaultMapper(model)
+	}
+	client := openai.NewClientWithConfig(config)
+
+	return &OpenAI{
+		Client: client,
+		model:  firstNonEmpty(cfg.Model, openai.GPT4oMini),
+	}, nil
+}
+
+// parseAzureDeployments parses the model to deployment mapping in form of "model=deployment,...",
+// a bare "deployment" is used for all models.
+func parseAzureDeployments(s string) (map[string]string, error) {
+	deployments := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		model, deployment, found := strings.Cut(pair, "=")
+		if !found {
+			model, deployment = "", model
+		}
+		model, deployment = strings.TrimSpace(model), strings.TrimSpace(deployment)
+		if deployment == "" {
+			return nil, fmt.Errorf("invalid azure deployment mapping [%s], expect model=deployment", pair)
+		}
+		deployments[model] = deployment
+	}
+	return deployments, nil
+}
+
+func (o *OpenAI) Chat(ctx context.Context, messages []Message, tools []Tool) (Message, error) {
+	req := openai.ChatCompletionRequest{
+		Model:    o.model,
+		Messages: toOpenAIMessages(messages),
+		Tools:    toOpenAITools(tools),
+	}
+	resp, err := o.Client.CreateChatCompletion(ctx, req)
+	if err != nil {
+		return Message{}, err
+	}
+	if len(resp.Choices) == 0 {
+		return Message{}, errors.New("no choices found")
+	}
+	return fromOpenAIMessage(resp.Choices[0].Message), nil
+}
+
+func (o *OpenAI) ChatStream(ctx context.Context, messages []Message, tools []Tool, onContent func(string)) (Message, error) {
 	req := openai.ChatCompletionRequest{
-		Model: openai.GPT4oMini,
+		Model:    o.model,
+		Messages: toOpenAIMessages(messages),
+		Tools:    toOpenAITools(tools),
+		Stream:   true,
+	}
+	stream, err := o.Client.CreateChatCompletionStream(ctx, req)
+	if err != nil {
+		return Message{}, err
+	}
+	defer stream.Close()
+
+	var content strings.Builder
+	// tool calls arrive in pieces, the first delta of each carries id & name,
+	// the following ones carry fragments of arguments, all identified by index.
+	var toolCalls []ToolCall
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Message{}, err
+		}
+		if len(resp.Choices) == 0 {
+			continue
+		}
+
+		delta := resp.Choices[0].Delta
+		if delta.Content != "" {
+			content.WriteString(delta.Content)
+			onContent(delta.Content)
+		}
+		for _, toolCall := range delta.ToolCalls {
+			index := len(toolCalls) - 1
+			if toolCall.Index != nil {
+				index = *toolCall.Index
+			} else if toolCall.ID != "" {
+				// some compatible servers omit index, a new id starts a new call then
+				index = len(toolCalls)
+			}
+			if index < 0 {
+				index = 0
+			}
+			for index >= len(toolCalls) {
+				toolCalls = append(toolCalls, ToolCall{})
+			}
+			if toolCall.ID != "" {
+				toolCalls[index].ID = toolCall.ID
+			}
+			if toolCall.Function.Name != "" {
+				toolCalls[index].Name = toolCall.Function.Name
+			}
+			toolCalls[index].Arguments += toolCall.Function.Arguments
+		}
+	}
+
+	return Message{
+		Role:      RoleAssistant,
+		Content:   content.String(),
+		ToolCalls: toolCalls,
+	}, nil
+}
+
+func (o *OpenAI) SendMessage(ctx context.Context, prompt, input string) (string, error) {
+	req := openai.ChatCompletionRequest{
+		Model: o.model,
 		Messages: []openai.ChatCompletionMessage{
 			{
 				Role:    openai.ChatMessageRoleSystem,
@@ -52,7 +199,7 @@ func (o *OpenAI) SendMessage(prompt, input string) (string, error) {
 			},
 		},
 	}
-	resp, err := o.Client.CreateChatCompletion(o.ctx, req)
+	resp, err := o.Client.CreateChatCompletion(ctx, req)
 	if err != nil {
 		return "", err
 	}
@@ -61,3 +208,64 @@ func (o *OpenAI) SendMessage(prompt, input string) (string, error) {
 	}
 	return resp.Choices[0].Message.Content, nil
 }
+
+func (o *OpenAI) Model() string {
+	return o.model
+}
+
+func (o *OpenAI) SetModel(model string) {
+	o.model = model
+}
+
+func toOpenAIMessages(messages []Message) []openai.ChatCompletionMessage {
+	msgs := make([]openai.ChatCompletionMessage, 0, len(messages))
+	for _, message := range messages {
+		msg := openai.ChatCompletionMessage{
+			Role:       message.Role,
+			Content:    message.Content,
+			ToolCallID: message.ToolCallID,
+		}
+		for _, toolCall := range message.ToolCalls {
+			msg.ToolCalls = append(msg.ToolCalls, openai.ToolCall{
+				ID:   toolCall.ID,
+				Type: openai.ToolTypeFunction,
+				Function: openai.FunctionCall{
+					Name:      toolCall.Name,
+					Arguments: toolCall.Arguments,
+				},
+			})
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
+
+func toOpenAITools(tools []Tool) []openai.Tool {
+	ts := make([]openai.Tool, 0, len(tools))
+	for _, tool := range tools {
+		ts = append(ts, openai.Tool{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+			},
+		})
+	}
+	return ts
+}
+
+func fromOpenAIMessage(msg openai.ChatCompletionMessage) Message {
+	message := Message{
+		Role:    msg.Role,
+		Content: msg.Content,
+	}
+	for _, toolCall := range msg.ToolCalls {
+		message.ToolCalls = append(message.ToolCalls, ToolCall{
+			ID:        toolCall.ID,
+			Name:      toolCall.Function.Name,
+			Arguments: toolCall.Function.Arguments,
+		})
+	}
+	return message
+}
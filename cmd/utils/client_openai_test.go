@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParseAzureDeployments(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    map[string]string
+		wantErr bool
+	}{
+		{"", map[string]string{}, false},
+		{"gpt-4o=prod-4o", map[string]string{"gpt-4o": "prod-4o"}, false},
+		{" gpt-4o = prod-4o , gpt-4o-mini=prod-mini, ", map[string]string{"gpt-4o": "prod-4o", "gpt-4o-mini": "prod-mini"}, false},
+		{"prod", map[string]string{"": "prod"}, false},
+		{"gpt-4o=", nil, true},
+		{"gpt-4o=prod-4o,=", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseAzureDeployments(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseAzureDeployments(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseAzureDeployments(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNewOpenAIWithoutSettings(t *testing.T) {
+	tests := []struct {
+		name    string
+		apiKey  string
+		baseURL string
+	}{
+		{"without API key", "", "https://example.openai.azure.com"},
+		{"without base URL", "test", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("API_KEY", "")
+			t.Setenv("BASE_URL", "")
+			cfg := LLMConfig{APIKey: tt.apiKey, BaseURL: tt.baseURL}
+			if _, err := NewOpenAI(cfg); err == nil {
+				t.Error("expect an error")
+			}
+			if _, err := NewAzureOpenAI(cfg); err == nil {
+				t.Error("expect an error of azure")
+			}
+		})
+	}
+}
+
+// newTestOpenAI serves /chat/completions by streaming the chunks as server-sent events.
+func newTestOpenAI(t *testing.T, chunks []string) *OpenAI {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range chunks {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"index\":0,\"delta\":%s}]}\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv("API_KEY", "test")
+	t.Setenv("BASE_URL", server.URL)
+	o, err := NewOpenAI(LLMConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return o
+}
+
+func TestOpenAIChatStreamToolCalls(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		want   []ToolCall
+	}{
+		{
+			name: "by index",
+			chunks: []string{
+				`{"role":"assistant","tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"getResource","arguments":""}}]}`,
+				`{"tool_calls":[{"index":0,"function":{"arguments":"{\"name\":"}}]}`,
+				`{"tool_calls":[{"index":1,"id":"call_2","type":"function","function":{"name":"getLogs","arguments":"{\"name\""}}]}`,
+				`{"tool_calls":[{"index":0,"function":{"arguments":"\"nginx\"}"}}]}`,
+				`{"tool_calls":[{"index":1,"function":{"arguments":":\"redis\"}"}}]}`,
+			},
+			want: []ToolCall{
+				{ID: "call_1", Name: "getResource", Arguments: `{"name":"nginx"}`},
+				{ID: "call_2", Name: "getLogs", Arguments: `{"name":"redis"}`},
+			},
+		},
+		{
+			name: "missing index",
+			chunks: []string{
+				`{"role":"assistant","tool_calls":[{"id":"call_1","type":"function","function":{"name":"getResource","arguments":"{\"name\":"}}]}`,
+				`{"tool_calls":[{"function":{"arguments":"\"nginx\"}"}}]}`,
+				`{"tool_calls":[{"id":"call_2","type":"function","function":{"name":"getLogs","arguments":"{}"}}]}`,
+			},
+			want: []ToolCall{
+				{ID: "call_1", Name: "getResource", Arguments: `{"name":"nginx"}`},
+				{ID: "call_2", Name: "getLogs", Arguments: `{}`},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := newTestOpenAI(t, tt.chunks)
+			msg, err := o.ChatStream(context.Background(), []Message{{Role: RoleUser, Content: "hi"}}, nil, func(string) {})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(msg.ToolCalls, tt.want) {
+				t.Errorf("tool calls = %+v, want %+v", msg.ToolCalls, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenAIChatStreamContent(t *testing.T) {
+	o := newTestOpenAI(t, []string{`{"role":"assistant","content":"Hello"}`, `{"content":", world"}`})
+	var streamed string
+	msg, err := o.ChatStream(context.Background(), []Message{{Role: RoleUser, Content: "hi"}}, nil, func(content string) {
+		streamed += content
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Role != RoleAssistant || msg.Content != "Hello, world" || streamed != msg.Content || len(msg.ToolCalls) != 0 {
+		t.Errorf("reply = %+v, streamed %q", msg, streamed)
+	}
+}
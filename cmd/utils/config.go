@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Config is the content of config file, e.g. ~/.k8s-copilot.yaml.
+//
+//	current-profile: dev
+//	profiles:
+//	  dev:
+//	    provider: openai
+//	    model: gpt-4o-mini
+//	    base-url: https://api.openai.com/v1
+//	    api-key:
+//	      env: OPENAI_API_KEY
+//	    namespace: dev
+//	  prod:
+//	    provider: ollama
+//	    base-url: http://bastion:11434
+//	    context: prod
+//	    safety:
+//	      read-only: true
+type Config struct {
+	// CurrentProfile is used if no profile is given by flag.
+	CurrentProfile string             `yaml:"current-profile"`
+	Profiles       map[string]Profile `yaml:"profiles"`
+}
+
+// Profile is a named set of settings.
+type Profile struct {
+	// LLM
+	Provider string       `yaml:"provider"`
+	Model    string       `yaml:"model"`
+	GenModel string       `yaml:"gen-model"`
+	BaseURL  string       `yaml:"base-url"`
+	APIKey   APIKeySource `yaml:"api-key"`
+	// Azure OpenAI only
+	APIVersion  string            `yaml:"api-version"`
+	Deployments map[string]string `yaml:"deployments"`
+
+	// Kubernetes
+	Kubeconfig string `yaml:"kubeconfig"`
+	Context    string `yaml:"context"`
+	Namespace  string `yaml:"namespace"`
+
+	Safety  Safety  `yaml:"safety"`
+	Prompts Prompts `yaml:"prompts"`
+}
+
+// APIKeySource tells where to read the API key from, only one of them shall be set.
+type APIKeySource struct {
+	// Value is the API key itself, prefer Env or File to keep it out of the config file.
+	Value string `yaml:"value"`
+	// Env is the name of environment variable holding the API key.
+	Env string `yaml:"env"`
+	// File is the path to a file holding the API key.
+	File string `yaml:"file"`
+}
+
+// Safety restricts what the copilot could do to the cluster.
+type Safety struct {
+	// Confirm asks for confirmation before changes are made, default to true.
+	Confirm *bool `yaml:"confirm"`
+	// ReadOnly disables all functions changing the cluster.
+	ReadOnly bool `yaml:"read-only"`
+	// DryRun only previews changes by server-side dry-run, nothing is applied.
+	DryRun bool `yaml:"dry-run"`
+	// ExecAllowlist are the commands allowed to run in containers, default to a few diagnostic ones.
+	ExecAllowlist []string `yaml:"exec-allowlist"`
+}
+
+// Prompts override the built-in system prompts.
+type Prompts struct {
+	// System instructs the model picking functions.
+	System string `yaml:"system"`
+	// Create instructs the model generating manifests.
+	Create string `yaml:"create"`
+	// Update instructs the model updating manifests.
+	Update string `yaml:"update"`
+}
+
+// LoadConfig reads config file, an empty config is returned if file doesn't exist & it's not required.
+func LoadConfig(path string, required bool) (*Config, error) {
+	data, err := os.ReadFile(ExpandHome(path))
+	if errors.Is(err, fs.ErrNotExist) && !required {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file [%s]: %w", path, err)
+	}
+	return config, nil
+}
+
+// Profile returns the profile of given name, or the current profile if name is empty.
+func (c *Config) Profile(name string) (Profile, error) {
+	if name == "" {
+		name = c.CurrentProfile
+	}
+	if name == "" {
+		return Profile{}, nil
+	}
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("profile [%s] not found in config file", name)
+	}
+	return profile, nil
+}
+
+// Resolve reads the API key from its source.
+func (s APIKeySource) Resolve() (string, error) {
+	switch {
+	case s.Value != "":
+		return s.Value, nil
+	case s.Env != "":
+		return os.Getenv(s.Env), nil
+	case s.File != "":
+		data, err := os.ReadFile(ExpandHome(s.File))
+		if err != nil {
+			return "", fmt.Errorf("failed to read API key file: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	default:
+		return "", nil
+	}
+}
+
+// ExpandHome expands the leading "~" to home directory.
+func ExpandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(homeDir, path[1:])
+}
@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testConfig = `current-profile: dev
+profiles:
+  dev:
+    provider: openai
+    model: gpt-4o-mini
+    namespace: dev
+  prod:
+    provider: ollama
+    context: prod
+    safety:
+      read-only: true
+`
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.yaml")
+	if config, err := LoadConfig(missing, false); err != nil || config.CurrentProfile != "" || len(config.Profiles) != 0 {
+		t.Errorf("missing optional config = %+v, %v, want an empty config", config, err)
+	}
+	if _, err := LoadConfig(missing, true); err == nil {
+		t.Error("expect an error for missing required config")
+	}
+	if _, err := LoadConfig(writeFile(t, "invalid.yaml", "profiles: [dev"), false); err == nil {
+		t.Error("expect an error for invalid config")
+	}
+
+	config, err := LoadConfig(writeFile(t, "config.yaml", testConfig), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.CurrentProfile != "dev" || len(config.Profiles) != 2 || !config.Profiles["prod"].Safety.ReadOnly {
+		t.Errorf("config = %+v", config)
+	}
+}
+
+func TestConfigProfile(t *testing.T) {
+	config, err := LoadConfig(writeFile(t, "config.yaml", testConfig), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	noCurrent := &Config{Profiles: config.Profiles}
+
+	tests := []struct {
+		name         string
+		config       *Config
+		profile      string
+		wantProvider string
+		wantErr      bool
+	}{
+		{"current profile", config, "", "openai", false},
+		{"given profile", config, "prod", "ollama", false},
+		{"unknown profile", config, "staging", "", true},
+		{"no current profile", noCurrent, "", "", false},
+		{"given profile without current", noCurrent, "prod", "ollama", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, err := tt.config.Profile(tt.profile)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if profile.Provider != tt.wantProvider {
+				t.Errorf("provider = %q, want %q", profile.Provider, tt.wantProvider)
+			}
+		})
+	}
+}
+
+func TestAPIKeySourceResolve(t *testing.T) {
+	t.Setenv("TEST_API_KEY", "from-env")
+	keyFile := writeFile(t, "key", " from-file\n")
+
+	tests := []struct {
+		name    string
+		source  APIKeySource
+		want    string
+		wantErr bool
+	}{
+		{"none", APIKeySource{}, "", false},
+		{"value", APIKeySource{Value: "from-value"}, "from-value", false},
+		{"env", APIKeySource{Env: "TEST_API_KEY"}, "from-env", false},
+		{"file", APIKeySource{File: keyFile}, "from-file", false},
+		{"value first", APIKeySource{Value: "from-value", Env: "TEST_API_KEY", File: keyFile}, "from-value", false},
+		{"missing file", APIKeySource{File: keyFile + ".missing"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.source.Resolve()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("key = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandHome(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip(err)
+	}
+	tests := map[string]string{
+		"~":                   home,
+		"~/.k8s-copilot.yaml": filepath.Join(home, ".k8s-copilot.yaml"),
+		"/etc/config.yaml":    "/etc/config.yaml",
+		"~user/config.yaml":   "~user/config.yaml",
+		"config.yaml":         "config.yaml",
+	}
+	for path, want := range tests {
+		if got := ExpandHome(path); got != want {
+			t.Errorf("ExpandHome(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+// Providers of LLM.
+const (
+	ProviderOpenAI    = "openai"
+	ProviderAzure     = "azure"
+	ProviderOllama    = "ollama"
+	ProviderAnthropic = "anthropic"
+)
+
+// Roles of messages in the dialogue.
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleTool      = "tool"
+)
+
+// LLM is a provider-neutral chat completion backend supporting function calling.
+type LLM interface {
+	// Chat sends the dialogue along with the available tools & returns the reply of the model.
+	Chat(ctx context.Context, messages []Message, tools []Tool) (Message, error)
+	// SendMessage sends a system prompt & user input without tools & returns the reply content.
+	SendMessage(ctx context.Context, prompt, input string) (string, error)
+	// Model returns the model in use.
+	Model() string
+	// SetModel switches to another model.
+	SetModel(model string)
+}
+
+// StreamLLM is an LLM which is able to stream the reply as it's produced.
+type StreamLLM interface {
+	LLM
+	// ChatStream works like Chat, besides it calls onContent with every piece of content as it's produced.
+	// The returned message carries the whole content & the tool calls reassembled from deltas.
+	ChatStream(ctx context.Context, messages []Message, tools []Tool, onContent func(string)) (Message, error)
+}
+
+// Message is a message in the dialogue.
+type Message struct {
+	Role    string
+	Content string
+	// ToolCalls are the functions the assistant asks to call.
+	ToolCalls []ToolCall
+	// ToolCallID is the call which a tool message responds to.
+	ToolCallID string
+}
+
+// ToolCall is a function call asked by the model.
+type ToolCall struct {
+	ID   string
+	Name string
+	// Arguments are encoded in JSON.
+	Arguments string
+}
+
+// Tool is a function which the model could call.
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  jsonschema.Definition
+}
+
+// LLMConfig configures the LLM backend, the ENV of provider takes precedence over it,
+// empty settings fall back to the defaults of provider.
+type LLMConfig struct {
+	Provider string
+	Model    string
+	BaseURL  string
+	APIKey   string
+	// Azure OpenAI only
+	APIVersion  string
+	Deployments map[string]string
+}
+
+// NewLLM creates the LLM backend of configured provider.
+func NewLLM(cfg LLMConfig) (LLM, error) {
+	switch cfg.Provider {
+	case ProviderOpenAI:
+		return NewOpenAI(cfg)
+	case ProviderAzure:
+		return NewAzureOpenAI(cfg)
+	case ProviderOllama:
+		return NewOllama(cfg)
+	case ProviderAnthropic:
+		return NewAnthropic(cfg)
+	default:
+		return nil, fmt.Errorf("unknown provider [%s], supported: %s, %s, %s, %s", cfg.Provider, ProviderOpenAI, ProviderAzure, ProviderOllama, ProviderAnthropic)
+	}
+}
+
+// firstNonEmpty returns the first non-empty value.
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
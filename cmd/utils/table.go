@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"path"
+)
+
+// tableAccept asks the API server for the Table representation, as kubectl get does.
+const tableAccept = "application/json;as=Table;v=v1;g=meta.k8s.io,application/json"
+
+// ListTable lists the resource as Table with the server-defined columns, including additionalPrinterColumns of CRDs.
+// Unlike ResourceInterface, the namespace is taken as is, so namespaced resources are listed across all namespaces
+// if namespace is empty.
+func (c *ClientGo) ListTable(ctx context.Context, mapping *meta.RESTMapping, namespace string, opts metav1.ListOptions) (*metav1.Table, error) {
+	return c.table(ctx, resourcePath(mapping, namespace), &opts)
+}
+
+// GetTable gets the object as Table with a single row.
+func (c *ClientGo) GetTable(ctx context.Context, mapping *meta.RESTMapping, namespace, name string) (*metav1.Table, error) {
+	namespace, err := c.NamespaceFor(mapping, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return c.table(ctx, path.Join(resourcePath(mapping, namespace), name), &metav1.GetOptions{})
+}
+
+func (c *ClientGo) table(ctx context.Context, absPath string, opts runtime.Object) (*metav1.Table, error) {
+	raw, err := c.ClientSet.CoreV1().RESTClient().Get().
+		AbsPath(absPath).
+		VersionedParams(opts, scheme.ParameterCodec).
+		Param("includeObject", string(metav1.IncludeMetadata)).
+		SetHeader("Accept", tableAccept).
+		DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+	table := &metav1.Table{}
+	if err := json.Unmarshal(raw, table); err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+// resourcePath builds the REST path of resource, e.g. /api/v1/namespaces/default/pods or /apis/apps/v1/deployments.
+func resourcePath(mapping *meta.RESTMapping, namespace string) string {
+	gvr := mapping.Resource
+	prefix := path.Join("/apis", gvr.Group, gvr.Version)
+	if gvr.Group == "" {
+		prefix = path.Join("/api", gvr.Version)
+	}
+	if namespace != "" {
+		return path.Join(prefix, "namespaces", namespace, gvr.Resource)
+	}
+	return path.Join(prefix, gvr.Resource)
+}